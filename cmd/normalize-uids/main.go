@@ -0,0 +1,44 @@
+// Command normalize-uids rewrites stored NFC UIDs into their canonical form
+// (upper-case hex, no separators) and reports values that are invalid or
+// collide after normalization. It is a dry run unless -apply is given.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"myapp/config"
+	"myapp/migrations"
+)
+
+func main() {
+	apply := flag.Bool("apply", false, "write the normalized UIDs instead of only reporting them")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+
+	config.ConnectDatabase()
+
+	report, err := migrations.NormalizeUIDs(config.DB, *apply)
+	if err != nil {
+		log.Fatal("Failed to normalize NFC UIDs:", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Fatal(err)
+	}
+
+	if !*apply {
+		log.Println("Dry run, re-run with -apply to write changes")
+	}
+	if len(report.Collisions) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"myapp/config"
+	"myapp/models"
+	"myapp/services"
+	"myapp/utils"
+)
+
+type MarkAbsentRequest struct {
+	SchoolID *uuid.UUID `json:"school_id,omitempty"`
+	Date     string     `json:"date,omitempty"` // YYYY-MM-DD, defaults to today in each school's time zone
+}
+
+type MarkAbsentResult struct {
+	SchoolID uuid.UUID `json:"school_id"`
+	School   string    `json:"school"`
+	Date     string    `json:"date"`
+	Marked   int       `json:"marked"`
+	Skipped  string    `json:"skipped,omitempty"`
+}
+
+// MarkAbsent manually runs the absent-marking job for one or all schools
+func (ac *AttendanceController) MarkAbsent(c echo.Context) error {
+	req := new(MarkAbsentRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var date *time.Time
+	if req.Date != "" {
+		parsed, err := utils.ParseDate(req.Date)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid date, expected YYYY-MM-DD",
+			})
+		}
+		date = &parsed
+	}
+
+	query := config.DB.Preload("Schedule.Overrides").Where("is_active = ?", true)
+	if req.SchoolID != nil {
+		query = query.Where("id = ?", *req.SchoolID)
+	}
+
+	var schools []models.School
+	if result := query.Find(&schools); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch schools",
+		})
+	}
+
+	if req.SchoolID != nil && len(schools) == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "School not found",
+		})
+	}
+
+	results := make([]MarkAbsentResult, 0, len(schools))
+	total := 0
+	for _, school := range schools {
+		day := utils.LocalDate(time.Now(), school.Location())
+		if date != nil {
+			day = *date
+		}
+
+		res := MarkAbsentResult{
+			SchoolID: school.ID,
+			School:   school.Name,
+			Date:     day.Format("2006-01-02"),
+		}
+
+		schoolDay, err := services.IsSchoolDay(config.DB, school, day)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to check school calendar",
+			})
+		}
+		if !schoolDay {
+			res.Skipped = "not a school day"
+			results = append(results, res)
+			continue
+		}
+
+		count, err := services.MarkAbsentees(school, day)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to mark absent students",
+			})
+		}
+
+		res.Marked = count
+		total += count
+		results = append(results, res)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Absent marking completed",
+		"total":   total,
+		"results": results,
+	})
+}
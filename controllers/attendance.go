@@ -6,22 +6,29 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 	"myapp/config"
 	"myapp/models"
+	"myapp/utils"
 )
 
 type AttendanceController struct{}
 
 type NFCAttendanceRequest struct {
-	NFCUID string `json:"nfc_uid" validate:"required"`
+	NFCUID   string `json:"nfc_uid" validate:"required"`
+	Location string `json:"location,omitempty"`     // reader location, used to resolve the attendance session; ignored for device requests
+	Payload  string `json:"ndef_payload,omitempty"` // signed NDEF record read from the card, see utils.SignCardPayload
 }
 
 type RegisterNFCRequest struct {
-	NFCUID    string    `json:"nfc_uid" validate:"required"`
-	Name      string    `json:"name" validate:"required"`
-	Class     string    `json:"class" validate:"required"`
-	StudentID string    `json:"student_id" validate:"required"`
-	SchoolID  uuid.UUID `json:"school_id" validate:"required"`
+	NFCUID    string     `json:"nfc_uid" validate:"required"`
+	Name      string     `json:"name" validate:"required"`
+	Class     string     `json:"class,omitempty"`    // class name, linked to the class of the current academic year
+	ClassID   *uuid.UUID `json:"class_id,omitempty"` // takes precedence over class
+	StudentID string     `json:"student_id" validate:"required"`
+	SchoolID  uuid.UUID  `json:"school_id" validate:"required"`
+	CardType  string     `json:"card_type,omitempty"` // nfc_card (default) or nfc_tag
+	Signed    bool       `json:"signed,omitempty"`    // require a signed NDEF payload, returned as ndef_payload
 }
 
 // RecordAttendance records attendance using NFC card
@@ -33,80 +40,18 @@ func (ac *AttendanceController) RecordAttendance(c echo.Context) error {
 		})
 	}
 
-	// Find student by NFC UID
-	var student models.Student
-	result := config.DB.Where("nfc_uid = ? AND is_active = ?", req.NFCUID, true).First(&student)
-	if result.Error != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Student not found or card not registered",
-		})
+	// Set by DeviceAuthMiddleware when a registered reader made the request
+	var device *models.Device
+	if d, ok := c.Get("device").(models.Device); ok {
+		device = &d
 	}
 
-	// Get today's date
-	today := time.Now().Truncate(24 * time.Hour)
-
-	// Check if attendance already exists for today
-	var attendance models.Attendance
-	result = config.DB.Where("student_id = ? AND date = ?", student.ID, today).First(&attendance)
-
-	if result.Error != nil {
-		// Create new attendance record (check-in)
-		now := time.Now()
-		attendance = models.Attendance{
-			ID:        uuid.New(),
-			StudentID: student.ID,
-			Date:      today,
-			TimeIn:    &now,
-			Status:    "present",
-		}
-
-		// Check if student is late (assuming school starts at 7:30 AM)
-		schoolStartTime := time.Date(now.Year(), now.Month(), now.Day(), 7, 30, 0, 0, now.Location())
-		if now.After(schoolStartTime) {
-			attendance.Status = "late"
-		}
-
-		result = config.DB.Create(&attendance)
-		if result.Error != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": "Failed to record attendance",
-			})
-		}
-
-		return c.JSON(http.StatusOK, map[string]interface{}{
-			"message":    "Check-in successful",
-			"student":    student.Name,
-			"class":      student.Class,
-			"time_in":    attendance.TimeIn,
-			"status":     attendance.Status,
-			"attendance": attendance,
-		})
-	} else {
-		// Update existing attendance record (check-out)
-		if attendance.TimeOut != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Student already checked out today",
-			})
-		}
-
-		now := time.Now()
-		attendance.TimeOut = &now
-
-		result = config.DB.Save(&attendance)
-		if result.Error != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": "Failed to update attendance",
-			})
-		}
+	now := time.Now()
+	event := newTapEvent(c, req.NFCUID, device, req.Location, now)
 
-		return c.JSON(http.StatusOK, map[string]interface{}{
-			"message":    "Check-out successful",
-			"student":    student.Name,
-			"class":      student.Class,
-			"time_out":   attendance.TimeOut,
-			"attendance": attendance,
-		})
-	}
+	status, body := processTap(req.NFCUID, req.Payload, device, req.Location, now, false, &event)
+	logTapEvent(event, status, body)
+	return c.JSON(status, body)
 }
 
 // RegisterNFCCard registers a new NFC card for a student
@@ -118,17 +63,39 @@ func (ac *AttendanceController) RegisterNFCCard(c echo.Context) error {
 		})
 	}
 
-	// Check if NFC UID already exists
-	var existingStudent models.Student
-	result := config.DB.Where("nfc_uid = ?", req.NFCUID).First(&existingStudent)
-	if result.Error == nil {
-		return c.JSON(http.StatusConflict, map[string]string{
-			"error": "NFC card already registered",
+	uid, err := utils.NormalizeUID(req.NFCUID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid nfc_uid, expected a 4, 7 or 10 byte hex UID",
+		})
+	}
+	req.NFCUID = uid
+
+	if req.CardType == "" {
+		req.CardType = models.CredentialTypeNFCCard
+	}
+	if req.CardType != models.CredentialTypeNFCCard && req.CardType != models.CredentialTypeNFCTag {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid card_type, expected nfc_card or nfc_tag",
 		})
 	}
 
+	var school models.School
+	if result := config.DB.Where("id = ? AND is_active = ?", req.SchoolID, true).First(&school); result.Error != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "School not found or inactive",
+		})
+	}
+
+	// Check if NFC UID already exists (including lost, blocked and retired
+	// cards and visitor cards)
+	if err := checkCardUID(config.DB, req.NFCUID); err != nil {
+		return credentialError(c, err, "Failed to register NFC card")
+	}
+
 	// Check if student ID already exists
-	result = config.DB.Where("student_id = ?", req.StudentID).First(&existingStudent)
+	var existingStudent models.Student
+	result := config.DB.Where("student_id = ?", req.StudentID).First(&existingStudent)
 	if result.Error == nil {
 		return c.JSON(http.StatusConflict, map[string]string{
 			"error": "Student ID already exists",
@@ -146,17 +113,60 @@ func (ac *AttendanceController) RegisterNFCCard(c echo.Context) error {
 		IsActive:  true,
 	}
 
-	result = config.DB.Create(&student)
-	if result.Error != nil {
+	card := models.NFCCard{
+		ID:               uuid.New(),
+		UID:              req.NFCUID,
+		Type:             req.CardType,
+		StudentID:        &student.ID,
+		Status:           models.CardStatusActive,
+		IssuedAt:         time.Now(),
+		RequireSignature: req.Signed,
+	}
+
+	// Sign before creating anything so a missing key leaves no half-registered card
+	var payload string
+	if card.RequireSignature {
+		if payload, err = cardPayload(card); err != nil {
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{
+				"error": "Card signing is not configured",
+			})
+		}
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		class, err := resolveStudentClass(tx, school, req.ClassID, req.Class)
+		if err != nil {
+			return err
+		}
+		student.ClassID = &class.ID
+		student.Class = class.Name
+
+		if err := tx.Create(&student).Error; err != nil {
+			return err
+		}
+		return tx.Create(&card).Error
+	})
+	if msg := studentClassError(err); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to register NFC card",
 		})
 	}
 
-	return c.JSON(http.StatusCreated, map[string]interface{}{
+	response := map[string]interface{}{
 		"message": "NFC card registered successfully",
 		"student": student,
-	})
+		"card":    card,
+	}
+	if payload != "" {
+		response["ndef_payload"] = payload
+		response["ndef_type"] = utils.CardPayloadMimeType
+	}
+	return c.JSON(http.StatusCreated, response)
 }
 
 // GetAttendanceHistory gets attendance history for a student
@@ -170,7 +180,7 @@ func (ac *AttendanceController) GetAttendanceHistory(c echo.Context) error {
 	}
 
 	var attendances []models.Attendance
-	result := config.DB.Where("student_id = ?", uuid).Order("date DESC").Limit(30).Find(&attendances)
+	result := config.DB.Preload("Session").Preload("Intervals", orderIntervals).Where("student_id = ?", uuid).Order("date DESC").Limit(30).Find(&attendances)
 	if result.Error != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to fetch attendance history",
@@ -178,25 +188,113 @@ func (ac *AttendanceController) GetAttendanceHistory(c echo.Context) error {
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"attendances": attendances,
+		"attendances":    attendances,
+		"auto_checkouts": countAutoCheckouts(attendances),
 	})
 }
 
-// GetTodayAttendance gets today's attendance for all students
+// GetTodayAttendance gets today's attendance for all students.
+// "Today" is evaluated per school in the school's own time zone.
+// Returns the daily check-ins unless session_id is given; class_id limits
+// the result to one class.
 func (ac *AttendanceController) GetTodayAttendance(c echo.Context) error {
-	today := time.Now().Truncate(24 * time.Hour)
+	var sessionID *uuid.UUID
+	if value := c.QueryParam("session_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid session ID",
+			})
+		}
+		sessionID = &id
+	}
 
-	var attendances []models.Attendance
-	result := config.DB.Preload("Student").Where("date = ?", today).Find(&attendances)
-	if result.Error != nil {
+	var classID *uuid.UUID
+	if value := c.QueryParam("class_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid class ID",
+			})
+		}
+		classID = &id
+	}
+
+	query := config.DB.Where("is_active = ?", true)
+	if schoolID := c.QueryParam("school_id"); schoolID != "" {
+		id, err := uuid.Parse(schoolID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid school ID",
+			})
+		}
+		query = query.Where("id = ?", id)
+	}
+
+	var schools []models.School
+	if result := query.Find(&schools); result.Error != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to fetch today's attendance",
 		})
 	}
 
+	// Group schools by their local date so each date needs a single query
+	now := time.Now()
+	schoolsByDate := make(map[time.Time][]uuid.UUID)
+	for _, school := range schools {
+		date := utils.LocalDate(now, school.Location())
+		schoolsByDate[date] = append(schoolsByDate[date], school.ID)
+	}
+
+	attendances := []models.Attendance{}
+	for date, schoolIDs := range schoolsByDate {
+		var found []models.Attendance
+		dayQuery := config.DB.Preload("Student").Preload("Intervals", orderIntervals).
+			Joins("JOIN students ON students.id = attendances.student_id").
+			Where("attendances.date = ? AND students.school_id IN ?", date, schoolIDs)
+		if sessionID != nil {
+			dayQuery = dayQuery.Where("attendances.session_id = ?", *sessionID)
+		} else {
+			dayQuery = dayQuery.Where("attendances.session_id IS NULL")
+		}
+		if classID != nil {
+			dayQuery = dayQuery.Where("students.class_id = ?", *classID)
+		}
+		result := dayQuery.Find(&found)
+		if result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to fetch today's attendance",
+			})
+		}
+		attendances = append(attendances, found...)
+	}
+
+	today := utils.LocalDate(now, models.DefaultLocation())
+	if len(schools) == 1 {
+		today = utils.LocalDate(now, schools[0].Location())
+	}
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"date":        today,
-		"attendances": attendances,
-		"total":       len(attendances),
+		"date":           today,
+		"attendances":    attendances,
+		"total":          len(attendances),
+		"auto_checkouts": countAutoCheckouts(attendances),
 	})
-}
\ No newline at end of file
+}
+
+// countAutoCheckouts counts the records whose checkout was inferred by the
+// auto-checkout job rather than tapped, so reports can show them separately
+func countAutoCheckouts(attendances []models.Attendance) int {
+	count := 0
+	for _, attendance := range attendances {
+		if attendance.AutoCheckout {
+			count++
+		}
+	}
+	return count
+}
+
+// orderIntervals preloads attendance intervals in time order
+func orderIntervals(db *gorm.DB) *gorm.DB {
+	return db.Order("time_in ASC")
+}
@@ -105,6 +105,14 @@ func (ac *AuthController) Register(c echo.Context) error {
 		})
 	}
 
+	// Public registration only creates regular accounts; admins are
+	// promoted in the database
+	if req.Role != "" && req.Role != "user" {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Only user accounts can be registered",
+		})
+	}
+
 	// Set default role if not provided
 	if req.Role == "" {
 		req.Role = "user"
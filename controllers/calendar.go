@@ -0,0 +1,419 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"myapp/config"
+	"myapp/models"
+	"myapp/services"
+	"myapp/utils"
+)
+
+type CalendarController struct{}
+
+type CalendarEventRequest struct {
+	SchoolID         *uuid.UUID `json:"school_id,omitempty"` // omit for events that apply to every school
+	Name             string     `json:"name" validate:"required"`
+	Type             string     `json:"type" validate:"required"`       // holiday, half_day
+	StartDate        string     `json:"start_date" validate:"required"` // YYYY-MM-DD
+	EndDate          string     `json:"end_date,omitempty"`             // YYYY-MM-DD, defaults to start_date
+	CheckoutOpenTime *string    `json:"checkout_open_time,omitempty"`   // HH:MM, half_day only
+	DismissalTime    *string    `json:"dismissal_time,omitempty"`       // HH:MM, half_day only
+}
+
+type AcademicTermRequest struct {
+	SchoolID  uuid.UUID `json:"school_id" validate:"required"`
+	Name      string    `json:"name" validate:"required"`
+	StartDate string    `json:"start_date" validate:"required"` // YYYY-MM-DD
+	EndDate   string    `json:"end_date" validate:"required"`   // YYYY-MM-DD
+}
+
+// ListEvents lists calendar events, optionally filtered by school and date range
+func (cc *CalendarController) ListEvents(c echo.Context) error {
+	query := config.DB.Order("start_date ASC")
+
+	if schoolID := c.QueryParam("school_id"); schoolID != "" {
+		id, err := uuid.Parse(schoolID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid school ID",
+			})
+		}
+		query = query.Where("school_id = ? OR school_id IS NULL", id)
+	}
+	if from := c.QueryParam("from"); from != "" {
+		date, err := utils.ParseDate(from)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid from date, expected YYYY-MM-DD",
+			})
+		}
+		query = query.Where("end_date >= ?", date)
+	}
+	if to := c.QueryParam("to"); to != "" {
+		date, err := utils.ParseDate(to)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid to date, expected YYYY-MM-DD",
+			})
+		}
+		query = query.Where("start_date <= ?", date)
+	}
+
+	var events []models.CalendarEvent
+	if result := query.Find(&events); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch calendar events",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"events": events,
+		"total":  len(events),
+	})
+}
+
+// CreateEvent creates a holiday or half-day
+func (cc *CalendarController) CreateEvent(c echo.Context) error {
+	req := new(CalendarEventRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	event := models.CalendarEvent{ID: uuid.New()}
+	if msg := applyCalendarEventRequest(&event, req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	if result := config.DB.Create(&event); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create calendar event",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Calendar event created successfully",
+		"event":   event,
+	})
+}
+
+// UpdateEvent updates a holiday or half-day
+func (cc *CalendarController) UpdateEvent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid event ID",
+		})
+	}
+
+	req := new(CalendarEventRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var event models.CalendarEvent
+	if result := config.DB.Where("id = ?", id).First(&event); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Calendar event not found",
+		})
+	}
+
+	if msg := applyCalendarEventRequest(&event, req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	if result := config.DB.Save(&event); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update calendar event",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Calendar event updated successfully",
+		"event":   event,
+	})
+}
+
+// DeleteEvent deletes a holiday or half-day
+func (cc *CalendarController) DeleteEvent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid event ID",
+		})
+	}
+
+	result := config.DB.Where("id = ?", id).Delete(&models.CalendarEvent{})
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete calendar event",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Calendar event not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Calendar event deleted successfully",
+	})
+}
+
+// ListTerms lists academic terms, optionally filtered by school
+func (cc *CalendarController) ListTerms(c echo.Context) error {
+	query := config.DB.Order("start_date ASC")
+
+	if schoolID := c.QueryParam("school_id"); schoolID != "" {
+		id, err := uuid.Parse(schoolID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid school ID",
+			})
+		}
+		query = query.Where("school_id = ?", id)
+	}
+
+	var terms []models.AcademicTerm
+	if result := query.Find(&terms); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch academic terms",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"terms": terms,
+		"total": len(terms),
+	})
+}
+
+// CreateTerm creates an academic term
+func (cc *CalendarController) CreateTerm(c echo.Context) error {
+	req := new(AcademicTermRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	term := models.AcademicTerm{ID: uuid.New()}
+	if msg := applyAcademicTermRequest(&term, req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	if result := config.DB.Create(&term); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create academic term",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Academic term created successfully",
+		"term":    term,
+	})
+}
+
+// UpdateTerm updates an academic term
+func (cc *CalendarController) UpdateTerm(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid term ID",
+		})
+	}
+
+	req := new(AcademicTermRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var term models.AcademicTerm
+	if result := config.DB.Where("id = ?", id).First(&term); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Academic term not found",
+		})
+	}
+
+	if msg := applyAcademicTermRequest(&term, req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	if result := config.DB.Save(&term); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update academic term",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Academic term updated successfully",
+		"term":    term,
+	})
+}
+
+// DeleteTerm deletes an academic term
+func (cc *CalendarController) DeleteTerm(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid term ID",
+		})
+	}
+
+	result := config.DB.Where("id = ?", id).Delete(&models.AcademicTerm{})
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete academic term",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Academic term not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Academic term deleted successfully",
+	})
+}
+
+// GetDay shows how a date is treated by a school's calendar
+func (cc *CalendarController) GetDay(c echo.Context) error {
+	schoolID, err := uuid.Parse(c.Param("school_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid school ID",
+		})
+	}
+
+	var school models.School
+	if result := config.DB.Preload("Schedule.Overrides").Where("id = ?", schoolID).First(&school); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "School not found",
+		})
+	}
+
+	date, err := utils.ParseDate(c.Param("date"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid date, expected YYYY-MM-DD",
+		})
+	}
+
+	day, err := services.ResolveDay(config.DB, school, date)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to check school calendar",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"school_id": school.ID,
+		"day":       day,
+	})
+}
+
+// applyCalendarEventRequest validates req and copies it onto event.
+// Returns an error message if the request is invalid.
+func applyCalendarEventRequest(event *models.CalendarEvent, req *CalendarEventRequest) string {
+	if req.Name == "" {
+		return "Name is required"
+	}
+	if req.Type != models.CalendarEventHoliday && req.Type != models.CalendarEventHalfDay {
+		return "Type must be holiday or half_day"
+	}
+
+	start, err := utils.ParseDate(req.StartDate)
+	if err != nil {
+		return "Invalid start_date, expected YYYY-MM-DD"
+	}
+	end := start
+	if req.EndDate != "" {
+		end, err = utils.ParseDate(req.EndDate)
+		if err != nil {
+			return "Invalid end_date, expected YYYY-MM-DD"
+		}
+	}
+	if end.Before(start) {
+		return "end_date must not be before start_date"
+	}
+
+	if req.CheckoutOpenTime != nil {
+		if req.Type != models.CalendarEventHalfDay {
+			return "checkout_open_time is only allowed for half_day events"
+		}
+		if _, _, err := utils.ParseClock(*req.CheckoutOpenTime); err != nil {
+			return "Invalid checkout_open_time, expected HH:MM"
+		}
+	}
+	if req.DismissalTime != nil {
+		if req.Type != models.CalendarEventHalfDay {
+			return "dismissal_time is only allowed for half_day events"
+		}
+		if _, _, err := utils.ParseClock(*req.DismissalTime); err != nil {
+			return "Invalid dismissal_time, expected HH:MM"
+		}
+	}
+
+	if req.SchoolID != nil {
+		var school models.School
+		if result := config.DB.Where("id = ?", *req.SchoolID).First(&school); result.Error != nil {
+			return "School not found"
+		}
+	}
+
+	event.SchoolID = req.SchoolID
+	event.Name = req.Name
+	event.Type = req.Type
+	event.StartDate = start
+	event.EndDate = end
+	event.CheckoutOpenTime = req.CheckoutOpenTime
+	event.DismissalTime = req.DismissalTime
+	return ""
+}
+
+// applyAcademicTermRequest validates req and copies it onto term.
+// Returns an error message if the request is invalid.
+func applyAcademicTermRequest(term *models.AcademicTerm, req *AcademicTermRequest) string {
+	if req.Name == "" {
+		return "Name is required"
+	}
+
+	start, err := utils.ParseDate(req.StartDate)
+	if err != nil {
+		return "Invalid start_date, expected YYYY-MM-DD"
+	}
+	end, err := utils.ParseDate(req.EndDate)
+	if err != nil {
+		return "Invalid end_date, expected YYYY-MM-DD"
+	}
+	if end.Before(start) {
+		return "end_date must not be before start_date"
+	}
+
+	var school models.School
+	if result := config.DB.Where("id = ?", req.SchoolID).First(&school); result.Error != nil {
+		return "School not found"
+	}
+
+	term.SchoolID = req.SchoolID
+	term.Name = req.Name
+	term.StartDate = start
+	term.EndDate = end
+	return ""
+}
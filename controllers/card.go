@@ -0,0 +1,509 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"myapp/config"
+	"myapp/models"
+	"myapp/utils"
+)
+
+var (
+	errCardUIDTaken       = errors.New("card uid already registered")
+	errCredentialLimit    = errors.New("active credential limit reached")
+	errCredentialNotFound = errors.New("student not found")
+)
+
+// qrTokenBytes is the length of generated QR tokens
+const qrTokenBytes = 16
+
+type CardController struct{}
+
+type RevokeCardRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+type IssueCredentialRequest struct {
+	Type string `json:"type" validate:"required"` // nfc_card, nfc_tag, qr_token
+	UID  string `json:"uid"`                      // generated for qr_token when empty
+}
+
+type UpdateCredentialLimitRequest struct {
+	MaxActiveCredentials int `json:"max_active_credentials" validate:"required"`
+}
+
+type ReplaceCardRequest struct {
+	NFCUID string `json:"nfc_uid"` // generated when replacing a qr_token and left empty
+	Reason string `json:"reason,omitempty"`
+}
+
+// ListCards lists credentials, filtered by uid, student_id, staff_id, type or status
+func (cc *CardController) ListCards(c echo.Context) error {
+	query := config.DB.Preload("Student").Preload("Staff").Order("issued_at DESC")
+
+	if uid := c.QueryParam("uid"); uid != "" {
+		query = query.Where("uid = ?", canonicalUID(uid))
+	}
+	if staffID := c.QueryParam("staff_id"); staffID != "" {
+		id, err := uuid.Parse(staffID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid staff ID",
+			})
+		}
+		query = query.Where("staff_id = ?", id)
+	}
+	if studentID := c.QueryParam("student_id"); studentID != "" {
+		id, err := uuid.Parse(studentID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid student ID",
+			})
+		}
+		query = query.Where("student_id = ?", id)
+	}
+	if cardType := c.QueryParam("type"); cardType != "" {
+		query = query.Where("type = ?", cardType)
+	}
+	if status := c.QueryParam("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var cards []models.NFCCard
+	if result := query.Limit(100).Find(&cards); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch cards",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"cards": cards,
+		"total": len(cards),
+	})
+}
+
+// ReportLost marks an active card as lost; taps with it are rejected
+func (cc *CardController) ReportLost(c echo.Context) error {
+	return cc.revokeCard(c, models.CardStatusLost)
+}
+
+// BlockCard blocks a card; taps with it are rejected
+func (cc *CardController) BlockCard(c echo.Context) error {
+	return cc.revokeCard(c, models.CardStatusBlocked)
+}
+
+// revokeCard moves a card to a revoked status
+func (cc *CardController) revokeCard(c echo.Context, status string) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid card ID",
+		})
+	}
+
+	req := new(RevokeCardRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var card models.NFCCard
+	if result := config.DB.Where("id = ?", id).First(&card); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Card not found",
+		})
+	}
+
+	if card.Status == models.CardStatusRetired {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Card is already retired",
+		})
+	}
+
+	now := time.Now()
+	card.Status = status
+	card.RevokedAt = &now
+	card.RevokedReason = req.Reason
+	if result := config.DB.Save(&card); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update card",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Card marked as " + status,
+		"card":    card,
+	})
+}
+
+// GenerateNDEFPayload signs a payload to write to the card as an NDEF
+// record and switches the card to signed mode, after which taps without a
+// valid payload are rejected. The payload's counter follows the last accepted
+// one, so a payload generated earlier but never used stays valid.
+func (cc *CardController) GenerateNDEFPayload(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid card ID",
+		})
+	}
+
+	var card models.NFCCard
+	if result := config.DB.Where("id = ?", id).First(&card); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Card not found",
+		})
+	}
+
+	if card.Status != models.CardStatusActive || card.Type == models.CredentialTypeQRToken || card.StudentID == nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Only active student NFC cards and tags can carry a signed payload",
+		})
+	}
+
+	payload, err := cardPayload(card)
+	if err == utils.ErrCardSigningDisabled {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "Card signing is not configured",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to sign card payload",
+		})
+	}
+
+	if !card.RequireSignature {
+		card.RequireSignature = true
+		if result := config.DB.Save(&card); result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to update card",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"card":         card,
+		"ndef_payload": payload,
+		"ndef_type":    utils.CardPayloadMimeType,
+	})
+}
+
+// IssueCredential adds a credential (card, tag or QR token) to a student,
+// keeping their existing credentials active
+func (cc *CardController) IssueCredential(c echo.Context) error {
+	studentID, err := uuid.Parse(c.Param("student_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid student ID",
+		})
+	}
+
+	req := new(IssueCredentialRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	if !models.IsCredentialType(req.Type) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid type, expected nfc_card, nfc_tag or qr_token",
+		})
+	}
+
+	uid, msg := credentialUID(req.Type, req.UID)
+	if msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	card := models.NFCCard{
+		ID:        uuid.New(),
+		UID:       uid,
+		Type:      req.Type,
+		StudentID: &studentID,
+		Status:    models.CardStatusActive,
+		IssuedAt:  time.Now(),
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := checkCredentialLimit(tx, studentID, nil); err != nil {
+			return err
+		}
+		if err := checkCardUID(tx, uid); err != nil {
+			return err
+		}
+		return tx.Create(&card).Error
+	})
+	if err != nil {
+		return credentialError(c, err, "Failed to issue credential")
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Credential issued successfully",
+		"card":    card,
+	})
+}
+
+// ReplaceCard issues a new credential of the same type to the holder of an
+// existing one. An old credential that is still active is retired; lost or
+// blocked credentials keep their status so their taps stay flagged.
+func (cc *CardController) ReplaceCard(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid card ID",
+		})
+	}
+
+	req := new(ReplaceCardRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var oldCard models.NFCCard
+	if result := config.DB.Where("id = ?", id).First(&oldCard); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Card not found",
+		})
+	}
+
+	uid, msg := credentialUID(oldCard.Type, req.NFCUID)
+	if msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	now := time.Now()
+	newCard := models.NFCCard{
+		ID:               uuid.New(),
+		UID:              uid,
+		Type:             oldCard.Type,
+		StudentID:        oldCard.StudentID,
+		StaffID:          oldCard.StaffID,
+		Status:           models.CardStatusActive,
+		IssuedAt:         now,
+		RequireSignature: oldCard.RequireSignature,
+	}
+
+	var payload string
+	if newCard.RequireSignature {
+		if payload, err = cardPayload(newCard); err != nil {
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{
+				"error": "Card signing is not configured",
+			})
+		}
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var student models.Student
+		if oldCard.StudentID != nil {
+			var err error
+			if student, err = checkCredentialLimit(tx, *oldCard.StudentID, &oldCard.ID); err != nil {
+				return err
+			}
+		}
+		if err := checkCardUID(tx, uid); err != nil {
+			return err
+		}
+
+		if oldCard.Status == models.CardStatusActive {
+			oldCard.Status = models.CardStatusRetired
+			oldCard.RevokedAt = &now
+			oldCard.RevokedReason = req.Reason
+			if err := tx.Save(&oldCard).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&newCard).Error; err != nil {
+			return err
+		}
+
+		// Keep the student's primary card in sync
+		if oldCard.StudentID == nil || student.NFCUID != oldCard.UID {
+			return nil
+		}
+		return tx.Model(&models.Student{}).Where("id = ?", student.ID).Update("nfc_uid", newCard.UID).Error
+	})
+	if err != nil {
+		return credentialError(c, err, "Failed to issue replacement card")
+	}
+
+	response := map[string]interface{}{
+		"message":  "Replacement card issued successfully",
+		"old_card": oldCard,
+		"card":     newCard,
+	}
+	if payload != "" {
+		response["ndef_payload"] = payload
+		response["ndef_type"] = utils.CardPayloadMimeType
+	}
+	return c.JSON(http.StatusCreated, response)
+}
+
+// UpdateCredentialLimit sets how many active credentials a student of the
+// school may hold. Existing credentials above a lowered limit stay active.
+func (cc *CardController) UpdateCredentialLimit(c echo.Context) error {
+	schoolID, err := uuid.Parse(c.Param("school_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid school ID",
+		})
+	}
+
+	req := new(UpdateCredentialLimitRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	if req.MaxActiveCredentials < 1 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "max_active_credentials must be at least 1",
+		})
+	}
+
+	var school models.School
+	if result := config.DB.Where("id = ?", schoolID).First(&school); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "School not found",
+		})
+	}
+
+	school.MaxActiveCredentials = req.MaxActiveCredentials
+	if result := config.DB.Save(&school); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update credential limit",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Credential limit updated successfully",
+		"school":  school,
+	})
+}
+
+// cardPayload signs the payload for the next tap of card. Only student
+// cards can be signed.
+func cardPayload(card models.NFCCard) (string, error) {
+	if card.StudentID == nil {
+		return "", utils.ErrInvalidCardPayload
+	}
+	return utils.SignCardPayload(utils.CardPayload{
+		StudentID: *card.StudentID,
+		CardID:    card.ID,
+		Counter:   card.SignatureCounter + 1,
+	})
+}
+
+// credentialUID returns the UID for a new credential, normalizing NFC UIDs
+// and generating QR tokens when none is given, or an error message
+func credentialUID(credentialType, uid string) (string, string) {
+	if credentialType != models.CredentialTypeQRToken {
+		if uid == "" {
+			return "", "nfc_uid is required"
+		}
+		normalized, err := utils.NormalizeUID(uid)
+		if err != nil {
+			return "", "Invalid nfc_uid, expected a 4, 7 or 10 byte hex UID"
+		}
+		return normalized, ""
+	}
+	if uid != "" {
+		return canonicalUID(uid), ""
+	}
+	token, err := utils.GenerateSecret(qrTokenBytes)
+	if err != nil {
+		return "", "Failed to generate QR token"
+	}
+	return token, ""
+}
+
+// checkCredentialLimit locks the student and fails with errCredentialLimit
+// when they already hold the school's maximum of active credentials.
+// exclude is a credential about to be replaced and is not counted.
+func checkCredentialLimit(tx *gorm.DB, studentID uuid.UUID, exclude *uuid.UUID) (models.Student, error) {
+	var student models.Student
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", studentID).Limit(1).Find(&student)
+	if result.Error != nil {
+		return student, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return student, errCredentialNotFound
+	}
+
+	var school models.School
+	if err := tx.Where("id = ?", student.SchoolID).First(&school).Error; err != nil {
+		return student, err
+	}
+	limit := school.MaxActiveCredentials
+	if limit < 1 {
+		limit = models.DefaultMaxActiveCredentials
+	}
+
+	query := tx.Model(&models.NFCCard{}).Where("student_id = ? AND status = ?", student.ID, models.CardStatusActive)
+	if exclude != nil {
+		query = query.Where("id <> ?", *exclude)
+	}
+	var active int64
+	if err := query.Count(&active).Error; err != nil {
+		return student, err
+	}
+	if active >= int64(limit) {
+		return student, errCredentialLimit
+	}
+
+	return student, nil
+}
+
+// checkCardUID fails with errCardUIDTaken when uid belongs to any credential,
+// including revoked ones, or to a visitor card
+func checkCardUID(tx *gorm.DB, uid string) error {
+	var existing int64
+	if err := tx.Model(&models.NFCCard{}).Where("uid = ?", uid).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return errCardUIDTaken
+	}
+	if err := tx.Model(&models.VisitorCard{}).Where("uid = ?", uid).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return errCardUIDTaken
+	}
+	return nil
+}
+
+// credentialError maps errors from credential transactions to responses
+func credentialError(c echo.Context, err error, fallback string) error {
+	switch err {
+	case errCardUIDTaken:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "NFC card already registered",
+		})
+	case errCredentialLimit:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Student already holds the maximum number of active credentials",
+		})
+	case errCredentialNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Student not found",
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": fallback,
+	})
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"myapp/config"
+	"myapp/middleware"
+	"myapp/models"
+	"myapp/utils"
+)
+
+// qrSecretBytes is the length of a student's QR token secret
+const qrSecretBytes = 32
+
+type QRAttendanceRequest struct {
+	QRToken  string `json:"qr_token" validate:"required"`
+	Location string `json:"location,omitempty"` // ignored for device requests
+}
+
+type ManualCheckInRequest struct {
+	StudentID uuid.UUID `json:"student_id" validate:"required"`
+	Reason    string    `json:"reason" validate:"required"` // e.g. "forgot card"
+	Location  string    `json:"location,omitempty"`
+}
+
+// GetQRToken returns the current rotating QR token of a student, to be shown
+// on a phone or screen when the student does not have their card. Only the
+// student's own account and school staff may fetch it.
+func (ac *AttendanceController) GetQRToken(c echo.Context) error {
+	studentID, err := uuid.Parse(c.Param("student_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid student ID",
+		})
+	}
+
+	var student models.Student
+	if result := config.DB.Where("id = ? AND is_active = ?", studentID, true).First(&student); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Student not found",
+		})
+	}
+
+	userID := c.Get("user_id").(uuid.UUID)
+	own := student.UserID != nil && *student.UserID == userID
+	if !own && !middleware.IsStaffOrAdmin(c) {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Access denied",
+		})
+	}
+
+	if student.QRSecret == "" {
+		secret, err := utils.GenerateSecret(qrSecretBytes)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to generate QR secret",
+			})
+		}
+		// Only set the secret if no concurrent request did so first
+		result := config.DB.Model(&models.Student{}).
+			Where("id = ? AND (qr_secret IS NULL OR qr_secret = '')", student.ID).
+			Update("qr_secret", secret)
+		if result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to generate QR secret",
+			})
+		}
+		if err := config.DB.Where("id = ?", student.ID).First(&student).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to generate QR secret",
+			})
+		}
+	}
+
+	token, expiresAt := utils.QRToken(student.ID, student.QRSecret, time.Now())
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"qr_token":       token,
+		"expires_at":     expiresAt,
+		"period_seconds": int(utils.QRTokenPeriod / time.Second),
+	})
+}
+
+// RecordQRAttendance records attendance from a scanned rotating QR token
+func (ac *AttendanceController) RecordQRAttendance(c echo.Context) error {
+	req := new(QRAttendanceRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	// Set by DeviceAuthMiddleware when a registered reader made the request
+	var device *models.Device
+	if d, ok := c.Get("device").(models.Device); ok {
+		device = &d
+	}
+
+	now := time.Now()
+	event := newTapEvent(c, "", device, req.Location, now)
+	event.Source = models.AttendanceSourceQR
+
+	status, body := processQRTap(req.QRToken, device, req.Location, now, &event)
+	logTapEvent(event, status, body)
+	return c.JSON(status, body)
+}
+
+// processQRTap verifies a QR token and records it like a card tap
+func processQRTap(token string, device *models.Device, location string, now time.Time, event *models.TapEvent) (int, map[string]interface{}) {
+	studentID, err := utils.ParseQRToken(token)
+	if err != nil {
+		return http.StatusBadRequest, map[string]interface{}{
+			"error": "Invalid QR token",
+		}
+	}
+
+	student, err := findActiveStudent(studentID)
+	if err != nil || !utils.VerifyQRToken(token, student.QRSecret, now) {
+		// Expired and forged tokens are not told apart
+		return http.StatusForbidden, map[string]interface{}{
+			"error": "QR token is invalid or expired",
+		}
+	}
+	event.StudentID = &student.ID
+
+	return recordTap(student, device, location, now, tapOrigin{Source: models.AttendanceSourceQR})
+}
+
+// ManualCheckIn lets a teacher check a student in or out without a card.
+// The entry goes through the same rules as a tap and is marked as manual
+// with the teacher and the reason. Requires a staff or admin account.
+func (ac *AttendanceController) ManualCheckIn(c echo.Context) error {
+	req := new(ManualCheckInRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.StudentID == uuid.Nil || req.Reason == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "student_id and reason are required",
+		})
+	}
+
+	userID := c.Get("user_id").(uuid.UUID)
+	now := time.Now()
+	event := newTapEvent(c, "", nil, req.Location, now)
+	event.Source = models.AttendanceSourceManual
+
+	var status int
+	var body map[string]interface{}
+	student, err := findActiveStudent(req.StudentID)
+	if err != nil {
+		status, body = http.StatusNotFound, map[string]interface{}{
+			"error": "Student not found",
+		}
+	} else {
+		event.StudentID = &student.ID
+		status, body = recordTap(student, nil, req.Location, now, tapOrigin{
+			Source:     models.AttendanceSourceManual,
+			RecordedBy: &userID,
+			Reason:     req.Reason,
+		})
+	}
+
+	logTapEvent(event, status, body)
+	return c.JSON(status, body)
+}
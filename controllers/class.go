@@ -0,0 +1,343 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"myapp/config"
+	"myapp/models"
+	"myapp/utils"
+)
+
+var errClassNameTaken = errors.New("class name already exists")
+
+type ClassController struct{}
+
+type ClassRequest struct {
+	SchoolID          uuid.UUID  `json:"school_id" validate:"required"`
+	AcademicYear      string     `json:"academic_year,omitempty"` // e.g. "2025/2026", defaults to the current academic year
+	Name              string     `json:"name" validate:"required"`
+	GradeLevel        int        `json:"grade_level,omitempty"` // defaults to the grade in the name
+	HomeroomTeacherID *uuid.UUID `json:"homeroom_teacher_id,omitempty"`
+	IsActive          *bool      `json:"is_active,omitempty"`
+}
+
+// ClassAttendanceEntry is one student of a class attendance roster
+type ClassAttendanceEntry struct {
+	Student    models.Student     `json:"student"`
+	Attendance *models.Attendance `json:"attendance"` // nil when the student has no record yet
+}
+
+// ListClasses lists classes.
+// Filters: school_id, academic_year, grade_level, include_inactive.
+func (cc *ClassController) ListClasses(c echo.Context) error {
+	query := config.DB.Preload("HomeroomTeacher").Order("grade_level ASC, name ASC")
+
+	if schoolID := c.QueryParam("school_id"); schoolID != "" {
+		id, err := uuid.Parse(schoolID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid school ID",
+			})
+		}
+		query = query.Where("school_id = ?", id)
+	}
+	if year := c.QueryParam("academic_year"); year != "" {
+		query = query.Where("academic_year = ?", year)
+	}
+	if value := c.QueryParam("grade_level"); value != "" {
+		grade, err := strconv.Atoi(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid grade_level",
+			})
+		}
+		query = query.Where("grade_level = ?", grade)
+	}
+	if c.QueryParam("include_inactive") != "true" {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var classes []models.Class
+	if result := query.Find(&classes); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch classes",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"classes": classes,
+		"total":   len(classes),
+	})
+}
+
+// CreateClass creates a class
+func (cc *ClassController) CreateClass(c echo.Context) error {
+	req := new(ClassRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	class := models.Class{ID: uuid.New(), IsActive: true}
+	if msg := applyClassRequest(&class, req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkClassName(tx, class); err != nil {
+			return err
+		}
+		return tx.Create(&class).Error
+	})
+	if err == errClassNameTaken {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Class already exists for this school and academic year",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create class",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Class created successfully",
+		"class":   class,
+	})
+}
+
+// UpdateClass updates a class. Renaming a class renames it on its students.
+func (cc *ClassController) UpdateClass(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid class ID",
+		})
+	}
+
+	req := new(ClassRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var class models.Class
+	if result := config.DB.Where("id = ?", id).First(&class); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Class not found",
+		})
+	}
+	if req.SchoolID != class.SchoolID {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "The school of a class cannot be changed",
+		})
+	}
+
+	if msg := applyClassRequest(&class, req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkClassName(tx, class); err != nil {
+			return err
+		}
+		if err := tx.Omit("HomeroomTeacher").Save(&class).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Student{}).Where("class_id = ?", class.ID).Update("class", class.Name).Error
+	})
+	if err == errClassNameTaken {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Class already exists for this school and academic year",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update class",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Class updated successfully",
+		"class":   class,
+	})
+}
+
+// GetClassAttendance gets the attendance roster of a class for a date
+// (YYYY-MM-DD, default today in the school's time zone): every active student
+// of the class with their record, if any. Returns the daily check-ins unless
+// session_id is given.
+func (cc *ClassController) GetClassAttendance(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("class_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid class ID",
+		})
+	}
+
+	var sessionID *uuid.UUID
+	if value := c.QueryParam("session_id"); value != "" {
+		sid, err := uuid.Parse(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid session ID",
+			})
+		}
+		sessionID = &sid
+	}
+
+	var class models.Class
+	if result := config.DB.Preload("HomeroomTeacher").Where("id = ?", id).First(&class); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Class not found",
+		})
+	}
+
+	var school models.School
+	if result := config.DB.Where("id = ?", class.SchoolID).First(&school); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch class attendance",
+		})
+	}
+
+	date := utils.LocalDate(time.Now(), school.Location())
+	if value := c.QueryParam("date"); value != "" {
+		if date, err = utils.ParseDate(value); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid date, expected YYYY-MM-DD",
+			})
+		}
+	}
+
+	var students []models.Student
+	result := config.DB.Where("class_id = ? AND is_active = ?", class.ID, true).Order("name ASC").Find(&students)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch class attendance",
+		})
+	}
+
+	studentIDs := make([]uuid.UUID, 0, len(students))
+	for _, student := range students {
+		studentIDs = append(studentIDs, student.ID)
+	}
+
+	var attendances []models.Attendance
+	if len(studentIDs) > 0 {
+		query := config.DB.Preload("Intervals", orderIntervals).
+			Where("student_id IN ? AND date = ?", studentIDs, date)
+		if sessionID != nil {
+			query = query.Where("session_id = ?", *sessionID)
+		} else {
+			query = query.Where("session_id IS NULL")
+		}
+		if result := query.Find(&attendances); result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to fetch class attendance",
+			})
+		}
+	}
+	byStudent := make(map[uuid.UUID]models.Attendance, len(attendances))
+	for _, attendance := range attendances {
+		byStudent[attendance.StudentID] = attendance
+	}
+
+	// "no_record" counts students who have neither tapped nor been marked yet
+	summary := map[string]int{"no_record": 0}
+	entries := make([]ClassAttendanceEntry, 0, len(students))
+	for _, student := range students {
+		entry := ClassAttendanceEntry{Student: student}
+		if attendance, ok := byStudent[student.ID]; ok {
+			entry.Attendance = &attendance
+			summary[attendance.Status]++
+		} else {
+			summary["no_record"]++
+		}
+		entries = append(entries, entry)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"date":           date,
+		"class":          class,
+		"students":       entries,
+		"summary":        summary,
+		"total":          len(entries),
+		"auto_checkouts": countAutoCheckouts(attendances),
+	})
+}
+
+// checkClassName fails with errClassNameTaken when another class of the same
+// school and academic year already has class's name
+func checkClassName(tx *gorm.DB, class models.Class) error {
+	var existing int64
+	err := tx.Model(&models.Class{}).
+		Where("school_id = ? AND academic_year = ? AND name = ? AND id <> ?", class.SchoolID, class.AcademicYear, class.Name, class.ID).
+		Count(&existing).Error
+	if err != nil {
+		return err
+	}
+	if existing > 0 {
+		return errClassNameTaken
+	}
+	return nil
+}
+
+// applyClassRequest validates req and copies it onto class.
+// Returns an error message if the request is invalid.
+func applyClassRequest(class *models.Class, req *ClassRequest) string {
+	name, grade := utils.NormalizeClassName(req.Name)
+	if name == "" {
+		return "Name is required"
+	}
+	if req.GradeLevel != 0 {
+		if req.GradeLevel < 1 || req.GradeLevel > 12 {
+			return "grade_level must be between 1 and 12"
+		}
+		grade = req.GradeLevel
+	}
+
+	var school models.School
+	if result := config.DB.Where("id = ?", req.SchoolID).First(&school); result.Error != nil {
+		return "School not found"
+	}
+
+	year := req.AcademicYear
+	if year == "" {
+		year = class.AcademicYear
+	}
+	if year == "" {
+		year = utils.AcademicYear(time.Now().In(school.Location()))
+	}
+	if !utils.ValidAcademicYear(year) {
+		return "Invalid academic_year, expected e.g. 2025/2026"
+	}
+
+	if req.HomeroomTeacherID != nil {
+		var teacher models.Staff
+		if result := config.DB.Where("id = ? AND school_id = ?", *req.HomeroomTeacherID, req.SchoolID).First(&teacher); result.Error != nil {
+			return "Homeroom teacher not found for this school"
+		}
+	}
+
+	class.SchoolID = req.SchoolID
+	class.AcademicYear = year
+	class.Name = name
+	class.GradeLevel = grade
+	class.HomeroomTeacherID = req.HomeroomTeacherID
+	if req.IsActive != nil {
+		class.IsActive = *req.IsActive
+	}
+	return ""
+}
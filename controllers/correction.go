@@ -0,0 +1,453 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"myapp/config"
+	"myapp/models"
+	"myapp/services"
+	"myapp/utils"
+)
+
+var (
+	errAttendanceExists   = errors.New("attendance already exists")
+	errAttendanceNotFound = errors.New("attendance not found")
+)
+
+type CreateAttendanceRequest struct {
+	StudentID uuid.UUID  `json:"student_id" validate:"required"`
+	Date      string     `json:"date" validate:"required"` // YYYY-MM-DD
+	SessionID *uuid.UUID `json:"session_id,omitempty"`
+	TimeIn    *time.Time `json:"time_in,omitempty"`
+	TimeOut   *time.Time `json:"time_out,omitempty"`
+	Status    string     `json:"status" validate:"required"`
+	Reason    string     `json:"reason" validate:"required"`
+}
+
+type UpdateAttendanceRequest struct {
+	TimeIn  *time.Time `json:"time_in,omitempty"`
+	TimeOut *time.Time `json:"time_out,omitempty"`
+	Status  *string    `json:"status,omitempty"`
+	Reason  string     `json:"reason" validate:"required"`
+}
+
+type VoidAttendanceRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// CreateAttendance adds an attendance record by hand, e.g. for a tap the
+// reader missed
+func (ac *AttendanceController) CreateAttendance(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+
+	req := new(CreateAttendanceRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Reason is required",
+		})
+	}
+	if !isAttendanceStatus(req.Status) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid status, expected present, late, absent, excused, sick or early_leave",
+		})
+	}
+	if msg := validateAttendanceTimes(req.TimeIn, req.TimeOut); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	date, err := utils.ParseDate(req.Date)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid date, expected YYYY-MM-DD",
+		})
+	}
+
+	var student models.Student
+	if result := config.DB.Preload("School.Schedule.Overrides").Where("id = ?", req.StudentID).First(&student); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Student not found",
+		})
+	}
+
+	if req.SessionID != nil {
+		var session models.AttendanceSession
+		if result := config.DB.Where("id = ? AND school_id = ?", *req.SessionID, student.SchoolID).First(&session); result.Error != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Session not found for the student's school",
+			})
+		}
+	}
+	if msg := validateAttendanceDate(date, student.School.Location(), req.TimeIn, req.TimeOut); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	schoolDay, err := services.IsSchoolDay(config.DB, student.School, date)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to check school calendar",
+		})
+	}
+
+	attendance := models.Attendance{
+		ID:           uuid.New(),
+		StudentID:    student.ID,
+		Date:         date,
+		SessionID:    req.SessionID,
+		TimeIn:       req.TimeIn,
+		TimeOut:      req.TimeOut,
+		Status:       req.Status,
+		NonSchoolDay: !schoolDay,
+		Source:       models.AttendanceSourceManual,
+		ManualReason: req.Reason,
+		RecordedBy:   &userID,
+	}
+	if req.TimeOut != nil {
+		attendance.CheckoutSource = models.AttendanceSourceManual
+		attendance.CheckoutReason = req.Reason
+		attendance.CheckoutRecordedBy = &userID
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// The unique indexes decide whether a record already exists
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&attendance)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// A voided record may be replaced; a live one has to be edited instead
+		var before *models.Attendance
+		if result.RowsAffected == 0 {
+			var existing models.Attendance
+			query := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("student_id = ? AND date = ?", student.ID, date)
+			if req.SessionID != nil {
+				query = query.Where("session_id = ?", *req.SessionID)
+			} else {
+				query = query.Where("session_id IS NULL")
+			}
+			if err := query.First(&existing).Error; err != nil {
+				return err
+			}
+			if !existing.VoidedAt.Valid {
+				return errAttendanceExists
+			}
+			voided := existing
+			before = &voided
+			if err := services.ReviveAttendance(tx, &existing); err != nil {
+				return err
+			}
+			attendance.ID = existing.ID
+			attendance.CreatedAt = existing.CreatedAt
+		}
+
+		if err := services.ResetIntervals(tx, &attendance); err != nil {
+			return err
+		}
+		if err := tx.Save(&attendance).Error; err != nil {
+			return err
+		}
+		return writeAttendanceAudit(tx, models.AuditActionCreate, attendance.ID, userID, req.Reason, before, &attendance)
+	})
+	if err == errAttendanceExists {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Attendance already exists for this student and date, edit it instead",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create attendance",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message":    "Attendance created successfully",
+		"attendance": attendance,
+	})
+}
+
+// UpdateAttendance corrects the times or status of an attendance record
+func (ac *AttendanceController) UpdateAttendance(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid attendance ID",
+		})
+	}
+
+	req := new(UpdateAttendanceRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Reason is required",
+		})
+	}
+	if req.Status != nil && !isAttendanceStatus(*req.Status) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid status, expected present, late, absent, excused, sick or early_leave",
+		})
+	}
+
+	var attendance models.Attendance
+	var msg string
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Limit(1).Find(&attendance)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAttendanceNotFound
+		}
+		before := attendance
+
+		if req.TimeIn != nil {
+			attendance.TimeIn = req.TimeIn
+		}
+		if req.TimeOut != nil {
+			attendance.TimeOut = req.TimeOut
+			attendance.CheckoutDeviceID = nil
+			attendance.CheckoutSource = models.AttendanceSourceManual
+			attendance.CheckoutReason = req.Reason
+			attendance.CheckoutRecordedBy = &userID
+			attendance.AutoCheckout = false
+		}
+		if req.Status != nil {
+			attendance.Status = *req.Status
+		}
+		if msg = validateAttendanceTimes(attendance.TimeIn, attendance.TimeOut); msg != "" {
+			return nil
+		}
+		var student models.Student
+		if err := tx.Preload("School").Where("id = ?", attendance.StudentID).First(&student).Error; err != nil {
+			return err
+		}
+		if msg = validateAttendanceDate(attendance.Date, student.School.Location(), req.TimeIn, req.TimeOut); msg != "" {
+			return nil
+		}
+
+		// Corrected times replace the tapped intervals
+		if req.TimeIn != nil || req.TimeOut != nil {
+			if err := services.ResetIntervals(tx, &attendance); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Save(&attendance).Error; err != nil {
+			return err
+		}
+		return writeAttendanceAudit(tx, models.AuditActionUpdate, attendance.ID, userID, req.Reason, &before, &attendance)
+	})
+	if err == errAttendanceNotFound {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Attendance not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update attendance",
+		})
+	}
+	if msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":    "Attendance updated successfully",
+		"attendance": attendance,
+	})
+}
+
+// VoidAttendance marks an attendance record made in error as voided. Voided
+// records are left out of reports and jobs but stay in the database and the
+// audit trail; a later tap, leave or manual entry replaces them.
+func (ac *AttendanceController) VoidAttendance(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid attendance ID",
+		})
+	}
+
+	req := new(VoidAttendanceRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Reason is required",
+		})
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var attendance models.Attendance
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Limit(1).Find(&attendance)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAttendanceNotFound
+		}
+
+		if err := tx.Model(&attendance).Update("voided_by", userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&attendance).Error; err != nil {
+			return err
+		}
+		return writeAttendanceAudit(tx, models.AuditActionVoid, attendance.ID, userID, req.Reason, &attendance, nil)
+	})
+	if err == errAttendanceNotFound {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Attendance not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to void attendance",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Attendance voided successfully",
+	})
+}
+
+// GetAttendanceAudit lists the changes made to an attendance record, oldest first
+func (ac *AttendanceController) GetAttendanceAudit(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid attendance ID",
+		})
+	}
+
+	var audits []models.AttendanceAudit
+	result := config.DB.Preload("User").Where("attendance_id = ?", id).Order("created_at ASC").Find(&audits)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch attendance audit",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"attendance_id": id,
+		"audit":         audits,
+		"total":         len(audits),
+	})
+}
+
+// isAttendanceStatus reports whether status is a valid attendance status
+func isAttendanceStatus(status string) bool {
+	switch status {
+	case "present", "late", "absent", models.LeaveTypeExcused, models.LeaveTypeSick, models.AttendanceStatusEarlyLeave:
+		return true
+	}
+	return false
+}
+
+// validateAttendanceTimes returns an error message if the times are inconsistent
+func validateAttendanceTimes(timeIn, timeOut *time.Time) string {
+	if timeOut != nil && timeIn == nil {
+		return "time_out requires time_in"
+	}
+	if timeOut != nil && timeOut.Before(*timeIn) {
+		return "time_out must not be before time_in"
+	}
+	return ""
+}
+
+// validateAttendanceDate returns an error message if a time does not fall on
+// date in the school's time zone
+func validateAttendanceDate(date time.Time, loc *time.Location, times ...*time.Time) string {
+	for _, t := range times {
+		if t != nil && !utils.LocalDate(*t, loc).Equal(date) {
+			return "time_in and time_out must fall on the attendance date"
+		}
+	}
+	return ""
+}
+
+// writeAttendanceAudit stores an audit entry with before and after snapshots;
+// nil snapshots are stored as null
+func writeAttendanceAudit(tx *gorm.DB, action string, attendanceID, userID uuid.UUID, reason string, before, after *models.Attendance) error {
+	audit := models.AttendanceAudit{
+		ID:           uuid.New(),
+		AttendanceID: attendanceID,
+		Action:       action,
+		UserID:       userID,
+		Reason:       reason,
+	}
+
+	var err error
+	if audit.Before, err = attendanceSnapshot(before); err != nil {
+		return err
+	}
+	if audit.After, err = attendanceSnapshot(after); err != nil {
+		return err
+	}
+
+	return tx.Create(&audit).Error
+}
+
+// attendanceSnapshot encodes the fields of an attendance record, without
+// its preloaded associations
+func attendanceSnapshot(a *models.Attendance) (json.RawMessage, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return json.Marshal(map[string]interface{}{
+		"id":                   a.ID,
+		"student_id":           a.StudentID,
+		"date":                 a.Date,
+		"session_id":           a.SessionID,
+		"time_in":              a.TimeIn,
+		"time_out":             a.TimeOut,
+		"status":               a.Status,
+		"non_school_day":       a.NonSchoolDay,
+		"leave_request_id":     a.LeaveRequestID,
+		"source":               a.Source,
+		"checkout_source":      a.CheckoutSource,
+		"auto_checkout":        a.AutoCheckout,
+		"present_minutes":      a.PresentMinutes,
+		"manual_reason":        a.ManualReason,
+		"recorded_by":          a.RecordedBy,
+		"checkout_reason":      a.CheckoutReason,
+		"checkout_recorded_by": a.CheckoutRecordedBy,
+		"voided_at":            a.VoidedAt,
+		"voided_by":            a.VoidedBy,
+	})
+}
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"myapp/config"
+	"myapp/models"
+	"myapp/utils"
+)
+
+// deviceSecretBytes is the length of generated device secrets
+const deviceSecretBytes = 32
+
+type DeviceController struct{}
+
+type CreateDeviceRequest struct {
+	SchoolID uuid.UUID `json:"school_id" validate:"required"`
+	Name     string    `json:"name" validate:"required"`
+	Location string    `json:"location,omitempty"`
+}
+
+type UpdateDeviceRequest struct {
+	Name      *string `json:"name,omitempty"`
+	Location  *string `json:"location,omitempty"`
+	IsEnabled *bool   `json:"is_enabled,omitempty"`
+}
+
+// ListDevices lists reader devices, optionally filtered by school
+func (dc *DeviceController) ListDevices(c echo.Context) error {
+	query := config.DB.Order("created_at ASC")
+
+	if schoolID := c.QueryParam("school_id"); schoolID != "" {
+		id, err := uuid.Parse(schoolID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid school ID",
+			})
+		}
+		query = query.Where("school_id = ?", id)
+	}
+
+	var devices []models.Device
+	if result := query.Find(&devices); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch devices",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"devices": devices,
+		"total":   len(devices),
+	})
+}
+
+// CreateDevice registers a reader device and returns its secret once
+func (dc *DeviceController) CreateDevice(c echo.Context) error {
+	req := new(CreateDeviceRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Name is required",
+		})
+	}
+
+	var school models.School
+	if result := config.DB.Where("id = ?", req.SchoolID).First(&school); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "School not found",
+		})
+	}
+
+	secret, err := utils.GenerateSecret(deviceSecretBytes)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to generate device secret",
+		})
+	}
+
+	device := models.Device{
+		ID:        uuid.New(),
+		SchoolID:  school.ID,
+		Name:      req.Name,
+		Location:  req.Location,
+		Secret:    secret,
+		IsEnabled: true,
+	}
+
+	if result := config.DB.Create(&device); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to register device",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Device registered successfully",
+		"device":  device,
+		"secret":  secret, // only returned here and on rotation
+	})
+}
+
+// UpdateDevice updates a device's name, location or enabled flag
+func (dc *DeviceController) UpdateDevice(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid device ID",
+		})
+	}
+
+	req := new(UpdateDeviceRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var device models.Device
+	if result := config.DB.Where("id = ?", id).First(&device); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Device not found",
+		})
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Name must not be empty",
+			})
+		}
+		device.Name = *req.Name
+	}
+	if req.Location != nil {
+		device.Location = *req.Location
+	}
+	if req.IsEnabled != nil {
+		device.IsEnabled = *req.IsEnabled
+	}
+
+	if result := config.DB.Save(&device); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update device",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Device updated successfully",
+		"device":  device,
+	})
+}
+
+// RotateDeviceSecret issues a new secret; the old one stops working immediately
+func (dc *DeviceController) RotateDeviceSecret(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid device ID",
+		})
+	}
+
+	var device models.Device
+	if result := config.DB.Where("id = ?", id).First(&device); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Device not found",
+		})
+	}
+
+	secret, err := utils.GenerateSecret(deviceSecretBytes)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to generate device secret",
+		})
+	}
+
+	device.Secret = secret
+	if result := config.DB.Save(&device); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to rotate device secret",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Device secret rotated successfully",
+		"device":  device,
+		"secret":  secret,
+	})
+}
+
+// DisableDevice disables a device. Devices are kept so attendance stays linked.
+func (dc *DeviceController) DisableDevice(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid device ID",
+		})
+	}
+
+	result := config.DB.Model(&models.Device{}).Where("id = ?", id).Update("is_enabled", false)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to disable device",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Device not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Device disabled successfully",
+	})
+}
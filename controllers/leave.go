@@ -0,0 +1,280 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"myapp/config"
+	"myapp/middleware"
+	"myapp/models"
+	"myapp/services"
+	"myapp/utils"
+)
+
+// maxLeaveDays limits the length of a single leave request
+const maxLeaveDays = 31
+
+var errLeaveAlreadyReviewed = errors.New("leave request already reviewed")
+
+type LeaveController struct{}
+
+type SubmitLeaveRequest struct {
+	StudentID     uuid.UUID `json:"student_id" validate:"required"`
+	StartDate     string    `json:"start_date" validate:"required"` // YYYY-MM-DD
+	EndDate       string    `json:"end_date,omitempty"`             // YYYY-MM-DD, defaults to start_date
+	Type          string    `json:"type" validate:"required"`       // excused, sick
+	Reason        string    `json:"reason" validate:"required"`
+	AttachmentRef string    `json:"attachment_ref,omitempty"`
+}
+
+type ReviewLeaveRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+// SubmitLeave submits an excused or sick leave request for a student
+func (lc *LeaveController) SubmitLeave(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+
+	req := new(SubmitLeaveRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Type != models.LeaveTypeExcused && req.Type != models.LeaveTypeSick {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Type must be excused or sick",
+		})
+	}
+	if req.Reason == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Reason is required",
+		})
+	}
+
+	start, err := utils.ParseDate(req.StartDate)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid start_date, expected YYYY-MM-DD",
+		})
+	}
+	end := start
+	if req.EndDate != "" {
+		end, err = utils.ParseDate(req.EndDate)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid end_date, expected YYYY-MM-DD",
+			})
+		}
+	}
+	if end.Before(start) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "end_date must not be before start_date",
+		})
+	}
+	if end.Sub(start) >= maxLeaveDays*24*time.Hour {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Leave request cannot span more than 31 days",
+		})
+	}
+
+	var student models.Student
+	if result := config.DB.Where("id = ? AND is_active = ?", req.StudentID, true).First(&student); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Student not found",
+		})
+	}
+
+	// Only the student's guardian or school staff may submit on their behalf
+	if !isGuardian(student, userID) && !middleware.IsStaffOrAdmin(c) {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Only the student's guardian or school staff can submit leave requests",
+		})
+	}
+
+	leave := models.LeaveRequest{
+		ID:            uuid.New(),
+		StudentID:     student.ID,
+		StartDate:     start,
+		EndDate:       end,
+		Type:          req.Type,
+		Reason:        req.Reason,
+		AttachmentRef: req.AttachmentRef,
+		State:         models.LeaveStatePending,
+		SubmittedBy:   userID,
+	}
+
+	if result := config.DB.Create(&leave); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to submit leave request",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message":       "Leave request submitted successfully",
+		"leave_request": leave,
+	})
+}
+
+// ListLeaves lists leave requests, optionally filtered by student and state.
+// Guardians only see requests of the students they are linked to.
+func (lc *LeaveController) ListLeaves(c echo.Context) error {
+	query := config.DB.Preload("Student").Order("created_at DESC")
+
+	if !middleware.IsStaffOrAdmin(c) {
+		userID := c.Get("user_id").(uuid.UUID)
+		query = query.Where("student_id IN (?)", config.DB.Model(&models.Student{}).Select("id").Where("guardian_user_id = ?", userID))
+	}
+
+	if studentID := c.QueryParam("student_id"); studentID != "" {
+		id, err := uuid.Parse(studentID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid student ID",
+			})
+		}
+		query = query.Where("student_id = ?", id)
+	}
+	if state := c.QueryParam("state"); state != "" {
+		query = query.Where("state = ?", state)
+	}
+
+	var leaves []models.LeaveRequest
+	if result := query.Limit(100).Find(&leaves); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch leave requests",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"leave_requests": leaves,
+		"total":          len(leaves),
+	})
+}
+
+// GetLeave returns a single leave request
+func (lc *LeaveController) GetLeave(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid leave request ID",
+		})
+	}
+
+	var leave models.LeaveRequest
+	if result := config.DB.Preload("Student").Where("id = ?", id).First(&leave); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Leave request not found",
+		})
+	}
+
+	if !isGuardian(leave.Student, c.Get("user_id").(uuid.UUID)) && !middleware.IsStaffOrAdmin(c) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Leave request not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"leave_request": leave,
+	})
+}
+
+// ApproveLeave approves a pending leave request and applies it to attendance
+func (lc *LeaveController) ApproveLeave(c echo.Context) error {
+	return lc.reviewLeave(c, models.LeaveStateApproved)
+}
+
+// RejectLeave rejects a pending leave request
+func (lc *LeaveController) RejectLeave(c echo.Context) error {
+	return lc.reviewLeave(c, models.LeaveStateRejected)
+}
+
+// reviewLeave moves a pending leave request to state
+func (lc *LeaveController) reviewLeave(c echo.Context, state string) error {
+	userID := c.Get("user_id").(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid leave request ID",
+		})
+	}
+
+	req := new(ReviewLeaveRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var leave models.LeaveRequest
+	if result := config.DB.Preload("Student.School.Schedule.Overrides").Where("id = ?", id).First(&leave); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Leave request not found",
+		})
+	}
+
+	if leave.State != models.LeaveStatePending {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Leave request has already been reviewed",
+		})
+	}
+
+	now := time.Now()
+	leave.State = state
+	leave.ReviewedBy = &userID
+	leave.ReviewedAt = &now
+	leave.ReviewNote = req.Note
+
+	applied := 0
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Only update a request that is still pending to avoid double review
+		result := tx.Model(&models.LeaveRequest{}).
+			Where("id = ? AND state = ?", leave.ID, models.LeaveStatePending).
+			Updates(map[string]interface{}{
+				"state":       leave.State,
+				"reviewed_by": leave.ReviewedBy,
+				"reviewed_at": leave.ReviewedAt,
+				"review_note": leave.ReviewNote,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errLeaveAlreadyReviewed
+		}
+
+		if state != models.LeaveStateApproved {
+			return nil
+		}
+
+		applied, err = services.ApplyLeave(tx, leave, leave.Student.School)
+		return err
+	})
+	if err == errLeaveAlreadyReviewed {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Leave request has already been reviewed",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to review leave request",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":             "Leave request " + state,
+		"leave_request":       leave,
+		"attendances_updated": applied,
+	})
+}
+
+// isGuardian reports whether userID is the guardian account linked to student
+func isGuardian(student models.Student, userID uuid.UUID) bool {
+	return student.GuardianUserID != nil && *student.GuardianUserID == userID
+}
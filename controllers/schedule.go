@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"myapp/config"
+	"myapp/models"
+	"myapp/utils"
+)
+
+type ScheduleController struct{}
+
+type ScheduleOverrideRequest struct {
+	Weekday          int     `json:"weekday"`
+	StartTime        *string `json:"start_time,omitempty"`
+	LateGraceMinutes *int    `json:"late_grace_minutes,omitempty"`
+	CheckoutOpenTime *string `json:"checkout_open_time,omitempty"`
+	AbsentCutoffTime *string `json:"absent_cutoff_time,omitempty"`
+	DismissalTime    *string `json:"dismissal_time,omitempty"`
+}
+
+type UpdateTimezoneRequest struct {
+	Timezone string `json:"timezone" validate:"required"`
+}
+
+type UpdateScheduleRequest struct {
+	StartTime          string                    `json:"start_time" validate:"required"`
+	LateGraceMinutes   int                       `json:"late_grace_minutes"`
+	CheckoutOpenTime   string                    `json:"checkout_open_time" validate:"required"`
+	AbsentCutoffTime   string                    `json:"absent_cutoff_time,omitempty"` // keeps the current value, 10:00 for a new schedule
+	DismissalTime      string                    `json:"dismissal_time,omitempty"`     // keeps the current value, 15:00 for a new schedule
+	DebounceSeconds    *int                      `json:"debounce_seconds,omitempty"`
+	MinDwellMinutes    *int                      `json:"min_dwell_minutes,omitempty"`
+	AllowIntervalExits *bool                     `json:"allow_interval_exits,omitempty"` // keeps the current value, true for a new schedule
+	SchoolDays         []int                     `json:"school_days,omitempty"`          // weekdays with classes, 0 = Sunday ... 6 = Saturday; keeps the current value, Monday-Friday for a new schedule
+	Overrides          []ScheduleOverrideRequest `json:"overrides"`
+}
+
+// GetSchedule returns the bell schedule of a school
+func (sc *ScheduleController) GetSchedule(c echo.Context) error {
+	schoolID, err := uuid.Parse(c.Param("school_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid school ID",
+		})
+	}
+
+	var school models.School
+	result := config.DB.Preload("Schedule.Overrides").Where("id = ?", schoolID).First(&school)
+	if result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "School not found",
+		})
+	}
+
+	if school.Schedule == nil {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"school_id":  school.ID,
+			"schedule":   models.DefaultDaySchedule(),
+			"is_default": true,
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"school_id":  school.ID,
+		"schedule":   school.Schedule,
+		"is_default": false,
+	})
+}
+
+// UpdateSchedule creates or replaces the bell schedule of a school
+func (sc *ScheduleController) UpdateSchedule(c echo.Context) error {
+	schoolID, err := uuid.Parse(c.Param("school_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid school ID",
+		})
+	}
+
+	req := new(UpdateScheduleRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := validateScheduleRequest(req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	var schoolDays string
+	if req.SchoolDays != nil {
+		days, msg := joinWeekdays(req.SchoolDays)
+		if msg != "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "School days must be between 0 (Sunday) and 6 (Saturday)",
+			})
+		}
+		schoolDays = days
+	}
+
+	var school models.School
+	if result := config.DB.Where("id = ?", schoolID).First(&school); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "School not found",
+		})
+	}
+
+	var schedule models.SchoolSchedule
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("school_id = ?", school.ID).First(&schedule)
+		if result.Error != nil {
+			schedule = models.SchoolSchedule{
+				ID:                 uuid.New(),
+				SchoolID:           school.ID,
+				AbsentCutoffTime:   models.DefaultAbsentCutoffTime,
+				DismissalTime:      models.DefaultDismissalTime,
+				DebounceSeconds:    models.DefaultDebounceSeconds,
+				MinDwellMinutes:    models.DefaultMinDwellMinutes,
+				SchoolDays:         models.DefaultSchoolDays,
+				AllowIntervalExits: models.DefaultAllowIntervalExits,
+			}
+		}
+
+		schedule.StartTime = req.StartTime
+		schedule.LateGraceMinutes = req.LateGraceMinutes
+		schedule.CheckoutOpenTime = req.CheckoutOpenTime
+		if req.AbsentCutoffTime != "" {
+			schedule.AbsentCutoffTime = req.AbsentCutoffTime
+		}
+		if schoolDays != "" {
+			schedule.SchoolDays = schoolDays
+		}
+		if req.DismissalTime != "" {
+			schedule.DismissalTime = req.DismissalTime
+		}
+		if req.DebounceSeconds != nil {
+			schedule.DebounceSeconds = *req.DebounceSeconds
+		}
+		if req.MinDwellMinutes != nil {
+			schedule.MinDwellMinutes = *req.MinDwellMinutes
+		}
+		if req.AllowIntervalExits != nil {
+			schedule.AllowIntervalExits = *req.AllowIntervalExits
+		}
+		if err := tx.Save(&schedule).Error; err != nil {
+			return err
+		}
+		// Inserting a new schedule leaves false to the column default
+		if !schedule.AllowIntervalExits {
+			if err := tx.Model(&schedule).Update("allow_interval_exits", false).Error; err != nil {
+				return err
+			}
+		}
+
+		// Overrides are replaced as a whole
+		if err := tx.Where("schedule_id = ?", schedule.ID).Delete(&models.ScheduleOverride{}).Error; err != nil {
+			return err
+		}
+
+		schedule.Overrides = make([]models.ScheduleOverride, 0, len(req.Overrides))
+		for _, o := range req.Overrides {
+			override := models.ScheduleOverride{
+				ID:               uuid.New(),
+				ScheduleID:       schedule.ID,
+				Weekday:          o.Weekday,
+				StartTime:        o.StartTime,
+				LateGraceMinutes: o.LateGraceMinutes,
+				CheckoutOpenTime: o.CheckoutOpenTime,
+				AbsentCutoffTime: o.AbsentCutoffTime,
+				DismissalTime:    o.DismissalTime,
+			}
+			if err := tx.Create(&override).Error; err != nil {
+				return err
+			}
+			schedule.Overrides = append(schedule.Overrides, override)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update schedule",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":  "Schedule updated successfully",
+		"schedule": schedule,
+	})
+}
+
+// UpdateTimezone sets the IANA time zone used to evaluate a school's attendance
+func (sc *ScheduleController) UpdateTimezone(c echo.Context) error {
+	schoolID, err := uuid.Parse(c.Param("school_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid school ID",
+		})
+	}
+
+	req := new(UpdateTimezoneRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if _, err := time.LoadLocation(req.Timezone); req.Timezone == "" || err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid timezone, expected an IANA name such as Asia/Jakarta",
+		})
+	}
+
+	var school models.School
+	if result := config.DB.Where("id = ?", schoolID).First(&school); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "School not found",
+		})
+	}
+
+	school.Timezone = req.Timezone
+	if result := config.DB.Save(&school); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update timezone",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Timezone updated successfully",
+		"school":  school,
+	})
+}
+
+// validateScheduleRequest returns an error message if the request is invalid
+func validateScheduleRequest(req *UpdateScheduleRequest) string {
+	if _, _, err := utils.ParseClock(req.StartTime); err != nil {
+		return "Invalid start_time, expected HH:MM"
+	}
+	if _, _, err := utils.ParseClock(req.CheckoutOpenTime); err != nil {
+		return "Invalid checkout_open_time, expected HH:MM"
+	}
+	if req.AbsentCutoffTime != "" {
+		if _, _, err := utils.ParseClock(req.AbsentCutoffTime); err != nil {
+			return "Invalid absent_cutoff_time, expected HH:MM"
+		}
+	}
+	if req.DismissalTime != "" {
+		if _, _, err := utils.ParseClock(req.DismissalTime); err != nil {
+			return "Invalid dismissal_time, expected HH:MM"
+		}
+	}
+	if req.LateGraceMinutes < 0 {
+		return "late_grace_minutes must not be negative"
+	}
+	if req.DebounceSeconds != nil && *req.DebounceSeconds < 0 {
+		return "debounce_seconds must not be negative"
+	}
+	if req.MinDwellMinutes != nil && *req.MinDwellMinutes < 0 {
+		return "min_dwell_minutes must not be negative"
+	}
+
+	seen := make(map[int]bool)
+	for _, o := range req.Overrides {
+		if o.Weekday < 0 || o.Weekday > 6 {
+			return "Override weekday must be between 0 (Sunday) and 6 (Saturday)"
+		}
+		if seen[o.Weekday] {
+			return "Duplicate override weekday"
+		}
+		seen[o.Weekday] = true
+
+		if o.StartTime != nil {
+			if _, _, err := utils.ParseClock(*o.StartTime); err != nil {
+				return "Invalid override start_time, expected HH:MM"
+			}
+		}
+		if o.CheckoutOpenTime != nil {
+			if _, _, err := utils.ParseClock(*o.CheckoutOpenTime); err != nil {
+				return "Invalid override checkout_open_time, expected HH:MM"
+			}
+		}
+		if o.AbsentCutoffTime != nil {
+			if _, _, err := utils.ParseClock(*o.AbsentCutoffTime); err != nil {
+				return "Invalid override absent_cutoff_time, expected HH:MM"
+			}
+		}
+		if o.DismissalTime != nil {
+			if _, _, err := utils.ParseClock(*o.DismissalTime); err != nil {
+				return "Invalid override dismissal_time, expected HH:MM"
+			}
+		}
+		if o.LateGraceMinutes != nil && *o.LateGraceMinutes < 0 {
+			return "Override late_grace_minutes must not be negative"
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"myapp/config"
+	"myapp/models"
+)
+
+type SchoolController struct{}
+
+type CreateSchoolRequest struct {
+	Name                 string `json:"name" validate:"required"`
+	Address              string `json:"address,omitempty"`
+	Phone                string `json:"phone,omitempty"`
+	Email                string `json:"email,omitempty"`
+	Timezone             string `json:"timezone,omitempty"`               // IANA name, defaults to Asia/Jakarta
+	MaxActiveCredentials int    `json:"max_active_credentials,omitempty"` // defaults to 2
+}
+
+type UpdateSchoolRequest struct {
+	Name                 *string `json:"name,omitempty"`
+	Address              *string `json:"address,omitempty"`
+	Phone                *string `json:"phone,omitempty"`
+	Email                *string `json:"email,omitempty"`
+	Timezone             *string `json:"timezone,omitempty"`
+	MaxActiveCredentials *int    `json:"max_active_credentials,omitempty"`
+	IsActive             *bool   `json:"is_active,omitempty"`
+}
+
+// ListSchools lists schools.
+// Filters: is_active (true/false), q (name).
+func (sc *SchoolController) ListSchools(c echo.Context) error {
+	query := config.DB.Order("name ASC")
+
+	if value := c.QueryParam("is_active"); value != "" {
+		active, err := strconv.ParseBool(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid is_active, expected true or false",
+			})
+		}
+		query = query.Where("is_active = ?", active)
+	}
+	if q := strings.TrimSpace(c.QueryParam("q")); q != "" {
+		query = query.Where("name ILIKE ?", "%"+q+"%")
+	}
+
+	var schools []models.School
+	if result := query.Find(&schools); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch schools",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"schools": schools,
+		"total":   len(schools),
+	})
+}
+
+// GetSchool gets a school with its schedule
+func (sc *SchoolController) GetSchool(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid school ID",
+		})
+	}
+
+	var school models.School
+	if result := config.DB.Preload("Schedule.Overrides").Where("id = ?", id).First(&school); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "School not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"school": school,
+	})
+}
+
+// CreateSchool creates a school
+func (sc *SchoolController) CreateSchool(c echo.Context) error {
+	req := new(CreateSchoolRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Name is required",
+		})
+	}
+	if req.Timezone == "" {
+		req.Timezone = models.DefaultTimezone
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid timezone, expected an IANA name such as Asia/Jakarta",
+		})
+	}
+	if req.MaxActiveCredentials == 0 {
+		req.MaxActiveCredentials = models.DefaultMaxActiveCredentials
+	}
+	if req.MaxActiveCredentials < 1 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "max_active_credentials must be at least 1",
+		})
+	}
+
+	school := models.School{
+		ID:                   uuid.New(),
+		Name:                 req.Name,
+		Address:              req.Address,
+		Phone:                req.Phone,
+		Email:                req.Email,
+		Timezone:             req.Timezone,
+		MaxActiveCredentials: req.MaxActiveCredentials,
+		IsActive:             true,
+	}
+
+	if result := config.DB.Create(&school); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create school",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "School created successfully",
+		"school":  school,
+	})
+}
+
+// UpdateSchool updates a school's details, time zone, credential limit or
+// active flag
+func (sc *SchoolController) UpdateSchool(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid school ID",
+		})
+	}
+
+	req := new(UpdateSchoolRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var school models.School
+	if result := config.DB.Where("id = ?", id).First(&school); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "School not found",
+		})
+	}
+
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Name must not be empty",
+			})
+		}
+		school.Name = *req.Name
+	}
+	if req.Address != nil {
+		school.Address = *req.Address
+	}
+	if req.Phone != nil {
+		school.Phone = *req.Phone
+	}
+	if req.Email != nil {
+		school.Email = *req.Email
+	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); *req.Timezone == "" || err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid timezone, expected an IANA name such as Asia/Jakarta",
+			})
+		}
+		school.Timezone = *req.Timezone
+	}
+	if req.MaxActiveCredentials != nil {
+		if *req.MaxActiveCredentials < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "max_active_credentials must be at least 1",
+			})
+		}
+		school.MaxActiveCredentials = *req.MaxActiveCredentials
+	}
+	if req.IsActive != nil {
+		school.IsActive = *req.IsActive
+	}
+
+	if result := config.DB.Omit("Schedule").Save(&school); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update school",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "School updated successfully",
+		"school":  school,
+	})
+}
+
+// DeactivateSchool deactivates a school. Schools are kept so students and
+// attendance stay linked; inactive schools are skipped by the scheduler and
+// cannot register new cards.
+func (sc *SchoolController) DeactivateSchool(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid school ID",
+		})
+	}
+
+	result := config.DB.Model(&models.School{}).Where("id = ?", id).Update("is_active", false)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to deactivate school",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "School not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "School deactivated successfully",
+	})
+}
@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"myapp/config"
+	"myapp/models"
+	"myapp/utils"
+)
+
+type SessionController struct{}
+
+type AttendanceSessionRequest struct {
+	SchoolID         uuid.UUID `json:"school_id" validate:"required"`
+	Name             string    `json:"name" validate:"required"`
+	Type             string    `json:"type" validate:"required"`       // period, extracurricular, exam
+	StartTime        string    `json:"start_time" validate:"required"` // HH:MM
+	EndTime          string    `json:"end_time" validate:"required"`   // HH:MM
+	LateGraceMinutes int       `json:"late_grace_minutes"`
+	Weekdays         []int     `json:"weekdays"` // 0 = Sunday ... 6 = Saturday, defaults to Monday-Friday
+	Location         string    `json:"location,omitempty"`
+}
+
+// ListSessions lists active attendance sessions, optionally filtered by school
+func (sc *SessionController) ListSessions(c echo.Context) error {
+	query := config.DB.Where("is_active = ?", true).Order("start_time ASC")
+
+	if schoolID := c.QueryParam("school_id"); schoolID != "" {
+		id, err := uuid.Parse(schoolID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid school ID",
+			})
+		}
+		query = query.Where("school_id = ?", id)
+	}
+
+	var sessions []models.AttendanceSession
+	if result := query.Find(&sessions); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch sessions",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"sessions": sessions,
+		"total":    len(sessions),
+	})
+}
+
+// CreateSession creates an attendance session
+func (sc *SessionController) CreateSession(c echo.Context) error {
+	req := new(AttendanceSessionRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	session := models.AttendanceSession{ID: uuid.New(), IsActive: true}
+	if msg := applySessionRequest(&session, req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	if result := config.DB.Create(&session); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create session",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Session created successfully",
+		"session": session,
+	})
+}
+
+// UpdateSession updates an attendance session
+func (sc *SessionController) UpdateSession(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid session ID",
+		})
+	}
+
+	req := new(AttendanceSessionRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var session models.AttendanceSession
+	if result := config.DB.Where("id = ?", id).First(&session); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Session not found",
+		})
+	}
+
+	if msg := applySessionRequest(&session, req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	if result := config.DB.Save(&session); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update session",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Session updated successfully",
+		"session": session,
+	})
+}
+
+// DeactivateSession deactivates an attendance session.
+// Sessions are kept so existing attendance records stay linked.
+func (sc *SessionController) DeactivateSession(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid session ID",
+		})
+	}
+
+	result := config.DB.Model(&models.AttendanceSession{}).Where("id = ?", id).Update("is_active", false)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to deactivate session",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Session not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Session deactivated successfully",
+	})
+}
+
+// applySessionRequest validates req and copies it onto session.
+// Returns an error message if the request is invalid.
+func applySessionRequest(session *models.AttendanceSession, req *AttendanceSessionRequest) string {
+	if req.Name == "" {
+		return "Name is required"
+	}
+	switch req.Type {
+	case models.SessionTypePeriod, models.SessionTypeExtracurricular, models.SessionTypeExam:
+	default:
+		return "Type must be period, extracurricular or exam"
+	}
+
+	startHour, startMinute, err := utils.ParseClock(req.StartTime)
+	if err != nil {
+		return "Invalid start_time, expected HH:MM"
+	}
+	endHour, endMinute, err := utils.ParseClock(req.EndTime)
+	if err != nil {
+		return "Invalid end_time, expected HH:MM"
+	}
+	if endHour*60+endMinute <= startHour*60+startMinute {
+		return "end_time must be after start_time"
+	}
+	if req.LateGraceMinutes < 0 {
+		return "late_grace_minutes must not be negative"
+	}
+
+	weekdays, msg := joinWeekdays(req.Weekdays)
+	if msg != "" {
+		return msg
+	}
+
+	var school models.School
+	if result := config.DB.Where("id = ?", req.SchoolID).First(&school); result.Error != nil {
+		return "School not found"
+	}
+
+	session.SchoolID = req.SchoolID
+	session.Name = req.Name
+	session.Type = req.Type
+	session.StartTime = req.StartTime
+	session.EndTime = req.EndTime
+	session.LateGraceMinutes = req.LateGraceMinutes
+	session.Weekdays = weekdays
+	session.Location = req.Location
+	return ""
+}
+
+// joinWeekdays formats weekdays as stored on sessions and shifts, defaulting
+// to Monday-Friday. Returns an error message if a weekday is out of range.
+func joinWeekdays(weekdays []int) (string, string) {
+	if len(weekdays) == 0 {
+		weekdays = []int{1, 2, 3, 4, 5}
+	}
+	parts := make([]string, 0, len(weekdays))
+	for _, day := range weekdays {
+		if day < 0 || day > 6 {
+			return "", "Weekdays must be between 0 (Sunday) and 6 (Saturday)"
+		}
+		parts = append(parts, strconv.Itoa(day))
+	}
+	return strings.Join(parts, ","), ""
+}
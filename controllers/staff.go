@@ -0,0 +1,598 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"myapp/config"
+	"myapp/models"
+	"myapp/utils"
+)
+
+var errEmployeeIDTaken = errors.New("employee id already exists")
+
+type StaffController struct{}
+
+type StaffRequest struct {
+	SchoolID   uuid.UUID  `json:"school_id" validate:"required"`
+	Name       string     `json:"name" validate:"required"`
+	EmployeeID string     `json:"employee_id" validate:"required"`
+	Position   string     `json:"position,omitempty"`
+	UserID     *uuid.UUID `json:"user_id,omitempty"`  // login account of the staff member
+	ShiftID    *uuid.UUID `json:"shift_id,omitempty"` // omit for staff without fixed hours
+	IsActive   *bool      `json:"is_active,omitempty"`
+	NFCUID     string     `json:"nfc_uid,omitempty"`   // create only, registers the staff member's first card
+	CardType   string     `json:"card_type,omitempty"` // nfc_card (default) or nfc_tag
+}
+
+type StaffShiftRequest struct {
+	SchoolID         uuid.UUID `json:"school_id" validate:"required"`
+	Name             string    `json:"name" validate:"required"`
+	StartTime        string    `json:"start_time" validate:"required"` // HH:MM
+	EndTime          string    `json:"end_time" validate:"required"`   // HH:MM
+	LateGraceMinutes int       `json:"late_grace_minutes"`
+	Weekdays         []int     `json:"weekdays"` // 0 = Sunday ... 6 = Saturday, defaults to Monday-Friday
+	IsActive         *bool     `json:"is_active,omitempty"`
+}
+
+// StaffAttendanceSummary is one row of the staff attendance report
+type StaffAttendanceSummary struct {
+	StaffID       uuid.UUID `json:"staff_id"`
+	Name          string    `json:"name"`
+	EmployeeID    string    `json:"employee_id"`
+	Position      string    `json:"position"`
+	DaysPresent   int       `json:"days_present"`
+	Absent        int       `json:"absent"`
+	Late          int       `json:"late"`
+	EarlyLeave    int       `json:"early_leave"`
+	MissingOut    int       `json:"missing_checkout"`
+	AutoCheckouts int       `json:"auto_checkouts"` // checkouts inferred by the auto-checkout job
+	WorkedMinutes int       `json:"worked_minutes"`
+}
+
+// ListStaff lists staff members, optionally filtered by school
+func (sc *StaffController) ListStaff(c echo.Context) error {
+	query := config.DB.Preload("Shift").Order("name ASC")
+
+	if schoolID := c.QueryParam("school_id"); schoolID != "" {
+		id, err := uuid.Parse(schoolID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid school ID",
+			})
+		}
+		query = query.Where("school_id = ?", id)
+	}
+	if c.QueryParam("include_inactive") != "true" {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var staff []models.Staff
+	if result := query.Find(&staff); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch staff",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"staff": staff,
+		"total": len(staff),
+	})
+}
+
+// CreateStaff registers a staff member, with their first card if nfc_uid is given
+func (sc *StaffController) CreateStaff(c echo.Context) error {
+	req := new(StaffRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	staff := models.Staff{ID: uuid.New(), IsActive: true}
+	if msg := applyStaffRequest(&staff, req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	var card *models.NFCCard
+	if req.NFCUID != "" {
+		if req.CardType == "" {
+			req.CardType = models.CredentialTypeNFCCard
+		}
+		if req.CardType != models.CredentialTypeNFCCard && req.CardType != models.CredentialTypeNFCTag {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid card_type, expected nfc_card or nfc_tag",
+			})
+		}
+		uid, msg := credentialUID(req.CardType, req.NFCUID)
+		if msg != "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": msg,
+			})
+		}
+		card = &models.NFCCard{
+			ID:       uuid.New(),
+			UID:      uid,
+			Type:     req.CardType,
+			StaffID:  &staff.ID,
+			Status:   models.CardStatusActive,
+			IssuedAt: time.Now(),
+		}
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&models.Staff{}).Where("employee_id = ?", staff.EmployeeID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errEmployeeIDTaken
+		}
+		if err := tx.Create(&staff).Error; err != nil {
+			return err
+		}
+		if card == nil {
+			return nil
+		}
+		if err := checkCardUID(tx, card.UID); err != nil {
+			return err
+		}
+		return tx.Create(card).Error
+	})
+	if err == errEmployeeIDTaken {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Employee ID already exists",
+		})
+	}
+	if err != nil {
+		return credentialError(c, err, "Failed to create staff member")
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Staff member created successfully",
+		"staff":   staff,
+		"card":    card,
+	})
+}
+
+// UpdateStaff updates a staff member
+func (sc *StaffController) UpdateStaff(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid staff ID",
+		})
+	}
+
+	req := new(StaffRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var staff models.Staff
+	if result := config.DB.Where("id = ?", id).First(&staff); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Staff member not found",
+		})
+	}
+
+	if msg := applyStaffRequest(&staff, req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	var existing int64
+	config.DB.Model(&models.Staff{}).Where("employee_id = ? AND id <> ?", staff.EmployeeID, staff.ID).Count(&existing)
+	if existing > 0 {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Employee ID already exists",
+		})
+	}
+
+	if result := config.DB.Omit("School", "Shift", "User").Save(&staff); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update staff member",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Staff member updated successfully",
+		"staff":   staff,
+	})
+}
+
+// IssueStaffCard adds a card or tag to a staff member
+func (sc *StaffController) IssueStaffCard(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid staff ID",
+		})
+	}
+
+	req := new(IssueCredentialRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	if req.Type != models.CredentialTypeNFCCard && req.Type != models.CredentialTypeNFCTag {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid type, expected nfc_card or nfc_tag",
+		})
+	}
+
+	uid, msg := credentialUID(req.Type, req.UID)
+	if msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	var staff models.Staff
+	if result := config.DB.Where("id = ?", id).First(&staff); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Staff member not found",
+		})
+	}
+
+	card := models.NFCCard{
+		ID:       uuid.New(),
+		UID:      uid,
+		Type:     req.Type,
+		StaffID:  &staff.ID,
+		Status:   models.CardStatusActive,
+		IssuedAt: time.Now(),
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkCardUID(tx, uid); err != nil {
+			return err
+		}
+		return tx.Create(&card).Error
+	})
+	if err != nil {
+		return credentialError(c, err, "Failed to issue card")
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Card issued successfully",
+		"card":    card,
+	})
+}
+
+// ListShifts lists active staff shifts, optionally filtered by school
+func (sc *StaffController) ListShifts(c echo.Context) error {
+	query := config.DB.Where("is_active = ?", true).Order("start_time ASC")
+
+	if schoolID := c.QueryParam("school_id"); schoolID != "" {
+		id, err := uuid.Parse(schoolID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid school ID",
+			})
+		}
+		query = query.Where("school_id = ?", id)
+	}
+
+	var shifts []models.StaffShift
+	if result := query.Find(&shifts); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch shifts",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"shifts": shifts,
+		"total":  len(shifts),
+	})
+}
+
+// CreateShift creates a staff shift
+func (sc *StaffController) CreateShift(c echo.Context) error {
+	req := new(StaffShiftRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	shift := models.StaffShift{ID: uuid.New(), IsActive: true}
+	if msg := applyShiftRequest(&shift, req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	if result := config.DB.Create(&shift); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create shift",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Shift created successfully",
+		"shift":   shift,
+	})
+}
+
+// UpdateShift updates a staff shift
+func (sc *StaffController) UpdateShift(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid shift ID",
+		})
+	}
+
+	req := new(StaffShiftRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var shift models.StaffShift
+	if result := config.DB.Where("id = ?", id).First(&shift); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Shift not found",
+		})
+	}
+
+	if msg := applyShiftRequest(&shift, req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	if result := config.DB.Save(&shift); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update shift",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Shift updated successfully",
+		"shift":   shift,
+	})
+}
+
+// ListStaffAttendance lists staff attendance filtered by school, staff
+// member, status and date range (from/to as YYYY-MM-DD, default today)
+func (sc *StaffController) ListStaffAttendance(c echo.Context) error {
+	query, msg := staffAttendanceQuery(c)
+	if msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	// Early leave is a flag on top of the check-in status
+	switch status := c.QueryParam("status"); status {
+	case "":
+	case models.AttendanceStatusEarlyLeave:
+		query = query.Where("staff_attendances.early_leave = ?", true)
+	default:
+		query = query.Where("staff_attendances.status = ?", status)
+	}
+
+	var attendances []models.StaffAttendance
+	result := query.Preload("Staff").Preload("Shift").
+		Order("staff_attendances.date DESC, staff_attendances.time_in ASC").Find(&attendances)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch staff attendance",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"attendances": attendances,
+		"total":       len(attendances),
+	})
+}
+
+// StaffAttendanceReport summarizes staff attendance per staff member over a
+// date range, for HR
+func (sc *StaffController) StaffAttendanceReport(c echo.Context) error {
+	query, msg := staffAttendanceQuery(c)
+	if msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	var attendances []models.StaffAttendance
+	if result := query.Preload("Staff").Find(&attendances); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch staff attendance",
+		})
+	}
+
+	summaries := make(map[uuid.UUID]*StaffAttendanceSummary)
+	order := []uuid.UUID{}
+	for _, attendance := range attendances {
+		summary, ok := summaries[attendance.StaffID]
+		if !ok {
+			summary = &StaffAttendanceSummary{StaffID: attendance.StaffID}
+			if attendance.Staff != nil {
+				summary.Name = attendance.Staff.Name
+				summary.EmployeeID = attendance.Staff.EmployeeID
+				summary.Position = attendance.Staff.Position
+			}
+			summaries[attendance.StaffID] = summary
+			order = append(order, attendance.StaffID)
+		}
+
+		if attendance.TimeIn == nil {
+			if attendance.Status == models.StaffStatusAbsent {
+				summary.Absent++
+			}
+			continue
+		}
+		summary.DaysPresent++
+		if attendance.Late {
+			summary.Late++
+		}
+		if attendance.EarlyLeave {
+			summary.EarlyLeave++
+		}
+		if attendance.AutoCheckout {
+			summary.AutoCheckouts++
+		}
+		if attendance.TimeOut == nil {
+			summary.MissingOut++
+			continue
+		}
+		summary.WorkedMinutes += int(attendance.TimeOut.Sub(*attendance.TimeIn) / time.Minute)
+	}
+
+	report := make([]StaffAttendanceSummary, 0, len(order))
+	for _, id := range order {
+		report = append(report, *summaries[id])
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"from":   c.QueryParam("from"),
+		"to":     c.QueryParam("to"),
+		"report": report,
+	})
+}
+
+// staffAttendanceQuery builds the filters shared by the staff attendance
+// list and report. Returns an error message if a filter is invalid.
+func staffAttendanceQuery(c echo.Context) (*gorm.DB, string) {
+	query := config.DB.Model(&models.StaffAttendance{}).
+		Joins("JOIN staffs ON staffs.id = staff_attendances.staff_id")
+
+	if schoolID := c.QueryParam("school_id"); schoolID != "" {
+		id, err := uuid.Parse(schoolID)
+		if err != nil {
+			return nil, "Invalid school ID"
+		}
+		query = query.Where("staffs.school_id = ?", id)
+	}
+	if staffID := c.QueryParam("staff_id"); staffID != "" {
+		id, err := uuid.Parse(staffID)
+		if err != nil {
+			return nil, "Invalid staff ID"
+		}
+		query = query.Where("staff_attendances.staff_id = ?", id)
+	}
+
+	from := utils.LocalDate(time.Now(), models.DefaultLocation())
+	if value := c.QueryParam("from"); value != "" {
+		date, err := utils.ParseDate(value)
+		if err != nil {
+			return nil, "Invalid from, expected YYYY-MM-DD"
+		}
+		from = date
+	}
+	to := from
+	if value := c.QueryParam("to"); value != "" {
+		date, err := utils.ParseDate(value)
+		if err != nil {
+			return nil, "Invalid to, expected YYYY-MM-DD"
+		}
+		to = date
+	}
+	if to.Before(from) {
+		return nil, "to must not be before from"
+	}
+
+	return query.Where("staff_attendances.date BETWEEN ? AND ?", from, to), ""
+}
+
+// applyStaffRequest validates req and copies it onto staff.
+// Returns an error message if the request is invalid.
+func applyStaffRequest(staff *models.Staff, req *StaffRequest) string {
+	if req.Name == "" || req.EmployeeID == "" {
+		return "Name and employee_id are required"
+	}
+
+	var school models.School
+	if result := config.DB.Where("id = ?", req.SchoolID).First(&school); result.Error != nil {
+		return "School not found"
+	}
+
+	if req.ShiftID != nil {
+		var shift models.StaffShift
+		if result := config.DB.Where("id = ? AND school_id = ?", *req.ShiftID, req.SchoolID).First(&shift); result.Error != nil {
+			return "Shift not found for this school"
+		}
+	}
+	if req.UserID != nil {
+		var user models.User
+		if result := config.DB.Where("id = ?", *req.UserID).First(&user); result.Error != nil {
+			return "User not found"
+		}
+		var linked int64
+		config.DB.Model(&models.Staff{}).Where("user_id = ? AND id <> ?", *req.UserID, staff.ID).Count(&linked)
+		if linked > 0 {
+			return "User is already linked to another staff member"
+		}
+	}
+
+	staff.SchoolID = req.SchoolID
+	staff.Name = req.Name
+	staff.EmployeeID = req.EmployeeID
+	staff.Position = req.Position
+	staff.UserID = req.UserID
+	staff.ShiftID = req.ShiftID
+	if req.IsActive != nil {
+		staff.IsActive = *req.IsActive
+	}
+	return ""
+}
+
+// applyShiftRequest validates req and copies it onto shift.
+// Returns an error message if the request is invalid.
+func applyShiftRequest(shift *models.StaffShift, req *StaffShiftRequest) string {
+	if req.Name == "" {
+		return "Name is required"
+	}
+
+	startHour, startMinute, err := utils.ParseClock(req.StartTime)
+	if err != nil {
+		return "Invalid start_time, expected HH:MM"
+	}
+	endHour, endMinute, err := utils.ParseClock(req.EndTime)
+	if err != nil {
+		return "Invalid end_time, expected HH:MM"
+	}
+	if endHour*60+endMinute <= startHour*60+startMinute {
+		return "end_time must be after start_time, overnight shifts are not supported"
+	}
+	if req.LateGraceMinutes < 0 {
+		return "late_grace_minutes must not be negative"
+	}
+
+	weekdays, msg := joinWeekdays(req.Weekdays)
+	if msg != "" {
+		return msg
+	}
+
+	var school models.School
+	if result := config.DB.Where("id = ?", req.SchoolID).First(&school); result.Error != nil {
+		return "School not found"
+	}
+
+	shift.SchoolID = req.SchoolID
+	shift.Name = req.Name
+	shift.StartTime = req.StartTime
+	shift.EndTime = req.EndTime
+	shift.LateGraceMinutes = req.LateGraceMinutes
+	shift.Weekdays = weekdays
+	if req.IsActive != nil {
+		shift.IsActive = *req.IsActive
+	}
+	return ""
+}
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"myapp/config"
+	"myapp/models"
+	"myapp/utils"
+)
+
+// findActiveStaff loads an active staff member with their shift and school
+// schedule preloaded for recordStaffTap
+func findActiveStaff(id uuid.UUID) (models.Staff, error) {
+	var staff models.Staff
+	result := config.DB.Preload("School.Schedule.Overrides").Preload("Shift").
+		Where("id = ? AND is_active = ?", id, true).First(&staff)
+	return staff, result.Error
+}
+
+// recordStaffTap applies a tap made at now by a staff member. The first tap
+// of the day is the check-in, late when after the shift start plus grace;
+// the next one is the checkout, an early leave when before the shift end.
+// Late and early leave are separate flags, so a late arrival who also left
+// early counts as both. Staff without a shift on the day are recorded as
+// present.
+func recordStaffTap(staff models.Staff, device *models.Device, now time.Time, origin tapOrigin) (int, map[string]interface{}) {
+	var deviceID *uuid.UUID
+	if device != nil {
+		if device.SchoolID != staff.SchoolID {
+			return http.StatusForbidden, map[string]interface{}{
+				"error": "Card belongs to a different school",
+				"code":  TapCodeWrongSchool,
+			}
+		}
+		deviceID = &device.ID
+	}
+
+	now = now.In(staff.School.Location())
+	today := utils.LocalDate(now, now.Location())
+
+	var shift *models.StaffShift
+	if staff.Shift != nil && staff.Shift.IsActive && staff.Shift.OnWeekday(today.Weekday()) {
+		shift = staff.Shift
+	}
+
+	status := "present"
+	var err error
+	if shift != nil {
+		status, err = checkInStatus(now, shift.StartTime, shift.LateGraceMinutes, true)
+	}
+	if err != nil {
+		return http.StatusInternalServerError, map[string]interface{}{
+			"error": "Invalid staff shift",
+		}
+	}
+
+	debounce := time.Duration(staff.School.Schedule.ForWeekday(today.Weekday()).DebounceSeconds) * time.Second
+
+	var code int
+	var body map[string]interface{}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		attendance := models.StaffAttendance{
+			ID:       uuid.New(),
+			StaffID:  staff.ID,
+			Date:     today,
+			TimeIn:   &now,
+			Status:   status,
+			Late:     status == "late",
+			DeviceID: deviceID,
+			Source:   origin.Source,
+		}
+		if shift != nil {
+			attendance.ShiftID = &shift.ID
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&attendance)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			code, body = http.StatusOK, staffCheckInResponse(staff, attendance)
+			return nil
+		}
+
+		// A record already exists; lock it so concurrent taps are evaluated one at a time
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("staff_id = ? AND date = ?", staff.ID, today).First(&attendance).Error; err != nil {
+			return err
+		}
+
+		// A replayed offline tap older than the recorded check-in, or a tap on
+		// a record the absence job created, becomes the check-in. A recorded
+		// check-in then becomes the checkout unless it was a repeat tap.
+		if attendance.TimeIn == nil || now.Before(*attendance.TimeIn) {
+			later, laterDevice := attendance.TimeIn, attendance.DeviceID
+			attendance.TimeIn = &now
+			attendance.Status = status
+			attendance.Late = status == "late"
+			attendance.DeviceID = deviceID
+			attendance.Source = origin.Source
+			if later != nil && attendance.TimeOut == nil && later.Sub(now) >= debounce {
+				attendance.TimeOut = later
+				attendance.CheckoutDeviceID = laterDevice
+				earlyLeave, err := staffEarlyLeave(shift, *later)
+				if err != nil {
+					return err
+				}
+				attendance.EarlyLeave = earlyLeave
+			}
+			if err := tx.Save(&attendance).Error; err != nil {
+				return err
+			}
+			code, body = http.StatusOK, staffCheckInResponse(staff, attendance)
+			return nil
+		}
+
+		if attendance.TimeOut != nil {
+			code, body = http.StatusBadRequest, map[string]interface{}{
+				"error": "Staff member already checked out today",
+				"code":  TapCodeAlreadyCheckedOut,
+			}
+			return nil
+		}
+
+		// Ignore accidental repeat taps right after check-in
+		if now.Sub(*attendance.TimeIn) < debounce {
+			code, body = http.StatusConflict, map[string]interface{}{
+				"error": "Staff member already checked in",
+				"code":  TapCodeAlreadyCheckedIn,
+			}
+			return nil
+		}
+
+		attendance.TimeOut = &now
+		attendance.CheckoutDeviceID = deviceID
+		earlyLeave, err := staffEarlyLeave(shift, now)
+		if err != nil {
+			return err
+		}
+		attendance.EarlyLeave = earlyLeave
+		if err := tx.Save(&attendance).Error; err != nil {
+			return err
+		}
+
+		code, body = http.StatusOK, map[string]interface{}{
+			"message":     "Check-out successful",
+			"action":      models.TapOutcomeCheckOut,
+			"staff":       staff.Name,
+			"position":    staff.Position,
+			"time_out":    attendance.TimeOut,
+			"status":      attendance.Status,
+			"early_leave": attendance.EarlyLeave,
+			"attendance":  attendance,
+		}
+		return nil
+	})
+	if err == errInvalidSchedule {
+		return http.StatusInternalServerError, map[string]interface{}{
+			"error": "Invalid staff shift",
+		}
+	}
+	if err != nil {
+		return http.StatusInternalServerError, map[string]interface{}{
+			"error": "Failed to record attendance",
+		}
+	}
+
+	return code, body
+}
+
+// staffEarlyLeave reports whether a checkout at timeOut is before the end of
+// shift. Staff without a shift never leave early.
+func staffEarlyLeave(shift *models.StaffShift, timeOut time.Time) (bool, error) {
+	if shift == nil {
+		return false, nil
+	}
+	shiftEnd, err := utils.ClockOn(timeOut, shift.EndTime)
+	if err != nil {
+		return false, errInvalidSchedule
+	}
+	return timeOut.Before(shiftEnd), nil
+}
+
+// staffCheckInResponse builds the response body for a staff check-in
+func staffCheckInResponse(staff models.Staff, attendance models.StaffAttendance) map[string]interface{} {
+	return map[string]interface{}{
+		"message":    "Check-in successful",
+		"action":     models.TapOutcomeCheckIn,
+		"staff":      staff.Name,
+		"position":   staff.Position,
+		"time_in":    attendance.TimeIn,
+		"status":     attendance.Status,
+		"attendance": attendance,
+	}
+}
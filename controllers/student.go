@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"myapp/config"
+	"myapp/models"
+	"myapp/utils"
+)
+
+// Page size limits for student queries
+const (
+	defaultStudentLimit = 50
+	maxStudentLimit     = 200
+)
+
+var (
+	errClassNotFound = errors.New("class not found")
+	errClassRequired = errors.New("class required")
+)
+
+type StudentController struct{}
+
+type UpdateStudentRequest struct {
+	Name      *string    `json:"name,omitempty"`
+	Class     *string    `json:"class,omitempty"`    // class name, linked to the class of the current academic year
+	ClassID   *uuid.UUID `json:"class_id,omitempty"` // takes precedence over class
+	StudentID *string    `json:"student_id,omitempty"`
+	IsActive  *bool      `json:"is_active,omitempty"`
+	// GuardianUserID links the guardian's user account; the nil UUID unlinks it
+	GuardianUserID *uuid.UUID `json:"guardian_user_id,omitempty"`
+	// UserID links the student's own user account; the nil UUID unlinks it
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+}
+
+// ListStudents lists students.
+// Filters: school_id, class_id, class (name), is_active (true/false), q (name or student ID), limit, offset.
+func (sc *StudentController) ListStudents(c echo.Context) error {
+	query := config.DB.Model(&models.Student{})
+
+	if schoolID := c.QueryParam("school_id"); schoolID != "" {
+		id, err := uuid.Parse(schoolID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid school ID",
+			})
+		}
+		query = query.Where("school_id = ?", id)
+	}
+	if classID := c.QueryParam("class_id"); classID != "" {
+		id, err := uuid.Parse(classID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid class ID",
+			})
+		}
+		query = query.Where("class_id = ?", id)
+	}
+	if class := c.QueryParam("class"); class != "" {
+		name, _ := utils.NormalizeClassName(class)
+		query = query.Where("class = ?", name)
+	}
+	if value := c.QueryParam("is_active"); value != "" {
+		active, err := strconv.ParseBool(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid is_active, expected true or false",
+			})
+		}
+		query = query.Where("is_active = ?", active)
+	}
+	if q := strings.TrimSpace(c.QueryParam("q")); q != "" {
+		pattern := "%" + q + "%"
+		query = query.Where("name ILIKE ? OR student_id ILIKE ?", pattern, pattern)
+	}
+
+	limit, offset, msg := parsePage(c, defaultStudentLimit, maxStudentLimit)
+	if msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch students",
+		})
+	}
+
+	var students []models.Student
+	result := query.Order("class ASC, name ASC").Limit(limit).Offset(offset).Find(&students)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch students",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"students": students,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+// GetStudent gets a student with all of their credentials
+func (sc *StudentController) GetStudent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid student ID",
+		})
+	}
+
+	var student models.Student
+	if result := config.DB.Preload("School").Preload("Classroom").Where("id = ?", id).First(&student); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Student not found",
+		})
+	}
+
+	var cards []models.NFCCard
+	if result := config.DB.Where("student_id = ?", student.ID).Order("issued_at DESC").Find(&cards); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch student cards",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"student": student,
+		"cards":   cards,
+	})
+}
+
+// UpdateStudent changes a student's name, class, student ID or active flag.
+// Cards are managed through the card endpoints and are left untouched.
+func (sc *StudentController) UpdateStudent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid student ID",
+		})
+	}
+
+	req := new(UpdateStudentRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var student models.Student
+	if result := config.DB.Where("id = ?", id).First(&student); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Student not found",
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Name must not be empty",
+			})
+		}
+		updates["name"] = *req.Name
+	}
+	if req.ClassID != nil || req.Class != nil {
+		var school models.School
+		if result := config.DB.Where("id = ?", student.SchoolID).First(&school); result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to update student",
+			})
+		}
+		name := ""
+		if req.Class != nil {
+			name = *req.Class
+		}
+		class, err := resolveStudentClass(config.DB, school, req.ClassID, name)
+		if msg := studentClassError(err); msg != "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": msg,
+			})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to update student",
+			})
+		}
+		updates["class_id"] = class.ID
+		updates["class"] = class.Name
+	}
+	if req.StudentID != nil && *req.StudentID != student.StudentID {
+		if strings.TrimSpace(*req.StudentID) == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Student ID must not be empty",
+			})
+		}
+		var existing int64
+		config.DB.Model(&models.Student{}).Where("student_id = ? AND id <> ?", *req.StudentID, student.ID).Count(&existing)
+		if existing > 0 {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Student ID already exists",
+			})
+		}
+		updates["student_id"] = *req.StudentID
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+	if req.GuardianUserID != nil {
+		if *req.GuardianUserID == uuid.Nil {
+			updates["guardian_user_id"] = nil
+		} else {
+			var guardian models.User
+			if result := config.DB.Where("id = ?", *req.GuardianUserID).First(&guardian); result.Error != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "Guardian user not found",
+				})
+			}
+			updates["guardian_user_id"] = guardian.ID
+		}
+	}
+	if req.UserID != nil {
+		if *req.UserID == uuid.Nil {
+			updates["user_id"] = nil
+		} else {
+			var account models.User
+			if result := config.DB.Where("id = ?", *req.UserID).First(&account); result.Error != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "User not found",
+				})
+			}
+			var linked int64
+			config.DB.Model(&models.Student{}).Where("user_id = ? AND id <> ?", account.ID, student.ID).Count(&linked)
+			if linked > 0 {
+				return c.JSON(http.StatusConflict, map[string]string{
+					"error": "User is already linked to another student",
+				})
+			}
+			updates["user_id"] = account.ID
+		}
+	}
+
+	if len(updates) > 0 {
+		if result := config.DB.Model(&student).Updates(updates); result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to update student",
+			})
+		}
+		config.DB.Where("id = ?", student.ID).First(&student)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Student updated successfully",
+		"student": student,
+	})
+}
+
+// DeactivateStudent deactivates a student, e.g. after they leave the school.
+// Students are kept so their attendance history stays linked; taps of an
+// inactive student are rejected.
+func (sc *StudentController) DeactivateStudent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid student ID",
+		})
+	}
+
+	result := config.DB.Model(&models.Student{}).Where("id = ?", id).Update("is_active", false)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to deactivate student",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Student not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Student deactivated successfully",
+	})
+}
+
+// resolveStudentClass returns the class to assign a student of school to:
+// classID when given, otherwise the existing class of the school's current
+// academic year whose name matches name once normalized. Classes are never
+// created here, so a typo cannot add a class; they are set up through
+// POST /admin/classes.
+func resolveStudentClass(tx *gorm.DB, school models.School, classID *uuid.UUID, name string) (models.Class, error) {
+	var class models.Class
+	if classID != nil {
+		if result := tx.Where("id = ? AND school_id = ?", *classID, school.ID).First(&class); result.Error != nil {
+			return class, errClassNotFound
+		}
+		return class, nil
+	}
+
+	normalized, _ := utils.NormalizeClassName(name)
+	if normalized == "" {
+		return class, errClassRequired
+	}
+	year := utils.AcademicYear(time.Now().In(school.Location()))
+	result := tx.Where("school_id = ? AND academic_year = ? AND name = ?", school.ID, year, normalized).First(&class)
+	if result.Error == gorm.ErrRecordNotFound {
+		return class, errClassNotFound
+	}
+	return class, result.Error
+}
+
+// studentClassError returns the message for errors of resolveStudentClass
+// caused by the request, or "" for other errors
+func studentClassError(err error) string {
+	switch err {
+	case errClassNotFound:
+		return "Class not found for this school"
+	case errClassRequired:
+		return "class or class_id is required"
+	}
+	return ""
+}
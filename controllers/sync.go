@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm/clause"
+	"myapp/config"
+	"myapp/models"
+)
+
+// Limits for offline tap synchronisation
+const (
+	maxSyncBatchSize = 500
+	maxSyncTapAge    = 7 * 24 * time.Hour
+	maxSyncClockSkew = 5 * time.Minute
+	// syncClaimTimeout is how long a claimed tap may stay pending before a
+	// retry may claim it again, e.g. after the server crashed mid-sync
+	syncClaimTimeout = 2 * time.Minute
+)
+
+type SyncTap struct {
+	ID       string    `json:"id" validate:"required"` // client generated idempotency ID, e.g. a UUID
+	NFCUID   string    `json:"nfc_uid" validate:"required"`
+	TappedAt time.Time `json:"tapped_at" validate:"required"` // RFC3339 device timestamp
+	Location string    `json:"location,omitempty"`
+}
+
+type SyncTapsRequest struct {
+	Taps []SyncTap `json:"taps" validate:"required"`
+}
+
+type SyncTapResult struct {
+	ID           string      `json:"id"`
+	Status       int         `json:"status"`
+	Outcome      string      `json:"outcome"`
+	Reason       string      `json:"reason,omitempty"`
+	AttendanceID interface{} `json:"attendance_id,omitempty"`
+	Duplicate    bool        `json:"duplicate"`
+}
+
+// SyncTaps ingests taps buffered by an offline reader. Taps are replayed in
+// chronological order of their device timestamps through the same logic as
+// RecordAttendance. A tap whose ID was already received is not applied again;
+// its stored outcome is returned instead. Tap IDs are scoped to the device.
+// Only registered reader devices may sync (DeviceAuthMiddleware).
+func (ac *AttendanceController) SyncTaps(c echo.Context) error {
+	req := new(SyncTapsRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.Taps) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "No taps to sync",
+		})
+	}
+	if len(req.Taps) > maxSyncBatchSize {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Too many taps in one batch, maximum is 500",
+		})
+	}
+
+	// Set by DeviceAuthMiddleware
+	device := c.Get("device").(models.Device)
+
+	// Replay in device time order so check-in precedes checkout
+	taps := make([]SyncTap, len(req.Taps))
+	copy(taps, req.Taps)
+	sort.SliceStable(taps, func(i, j int) bool {
+		return taps[i].TappedAt.Before(taps[j].TappedAt)
+	})
+
+	now := time.Now()
+	results := make(map[string]SyncTapResult, len(taps))
+	for _, tap := range taps {
+		results[tap.ID] = syncTap(c, tap, &device, now)
+	}
+
+	// Answer in the order the client sent the taps
+	ordered := make([]SyncTapResult, 0, len(req.Taps))
+	for _, tap := range req.Taps {
+		ordered = append(ordered, results[tap.ID])
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Sync completed",
+		"results": ordered,
+		"total":   len(ordered),
+	})
+}
+
+// syncTap validates, claims and applies a single buffered tap
+func syncTap(c echo.Context, tap SyncTap, device *models.Device, now time.Time) SyncTapResult {
+	res := SyncTapResult{ID: tap.ID}
+
+	if tap.ID == "" || tap.NFCUID == "" || tap.TappedAt.IsZero() {
+		res.Status = http.StatusBadRequest
+		res.Outcome = models.TapOutcomeRejected
+		res.Reason = "id, nfc_uid and tapped_at are required"
+		return res
+	}
+	if tap.TappedAt.After(now.Add(maxSyncClockSkew)) {
+		res.Status = http.StatusBadRequest
+		res.Outcome = models.TapOutcomeRejected
+		res.Reason = "tapped_at is in the future"
+		return res
+	}
+	if now.Sub(tap.TappedAt) > maxSyncTapAge {
+		res.Status = http.StatusBadRequest
+		res.Outcome = models.TapOutcomeRejected
+		res.Reason = "tapped_at is too old to sync"
+		return res
+	}
+
+	// Claim the idempotency ID; a conflict means the tap was already received
+	event := newTapEvent(c, tap.NFCUID, device, tap.Location, tap.TappedAt)
+	event.ClientTapID = &tap.ID
+	event.Outcome = models.TapOutcomePending
+	result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&event)
+	if result.Error != nil {
+		res.Status = http.StatusInternalServerError
+		res.Outcome = models.TapOutcomeError
+		res.Reason = "Failed to store tap event"
+		return res
+	}
+	if result.RowsAffected == 0 {
+		var existing models.TapEvent
+		if err := config.DB.Where("device_id = ? AND client_tap_id = ?", device.ID, tap.ID).First(&existing).Error; err != nil {
+			res.Status = http.StatusInternalServerError
+			res.Outcome = models.TapOutcomeError
+			res.Reason = "Failed to load previous tap"
+			return res
+		}
+
+		if existing.Outcome != models.TapOutcomePending {
+			res.Status = http.StatusOK
+			res.Outcome = existing.Outcome
+			res.Reason = existing.Reason
+			if existing.AttendanceID != nil {
+				res.AttendanceID = *existing.AttendanceID
+			}
+			res.Duplicate = true
+			return res
+		}
+
+		// A claim that stayed pending past the timeout was abandoned; take it
+		// over, unless a concurrent retry already did
+		claim := config.DB.Model(&models.TapEvent{}).
+			Where("id = ? AND outcome = ? AND created_at < ?", existing.ID, models.TapOutcomePending, now.Add(-syncClaimTimeout)).
+			Update("created_at", now)
+		if claim.Error != nil {
+			res.Status = http.StatusInternalServerError
+			res.Outcome = models.TapOutcomeError
+			res.Reason = "Failed to load previous tap"
+			return res
+		}
+		if claim.RowsAffected == 0 {
+			res.Status = http.StatusConflict
+			res.Outcome = models.TapOutcomePending
+			res.Reason = "Tap is still being processed"
+			res.Duplicate = true
+			return res
+		}
+		existing.StudentID, existing.StaffID, existing.VisitorPassID = nil, nil, nil
+		event = existing
+	}
+
+	status, body := processTap(tap.NFCUID, "", device, tap.Location, tap.TappedAt, true, &event)
+	fillTapEvent(&event, status, body)
+	if err := config.DB.Save(&event).Error; err != nil {
+		// The tap was applied but its outcome could not be stored; the claim
+		// stays pending and a retry after syncClaimTimeout replays it, which
+		// the debounce window turns into a no-op
+		log.Println("Failed to store synced tap event:", err)
+		res.Status = http.StatusInternalServerError
+		res.Outcome = models.TapOutcomeError
+		res.Reason = "Failed to store tap event"
+		return res
+	}
+
+	res.Status = status
+	res.Outcome = event.Outcome
+	res.Reason = event.Reason
+	if event.AttendanceID != nil {
+		res.AttendanceID = *event.AttendanceID
+	}
+	return res
+}
@@ -0,0 +1,679 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"myapp/config"
+	"myapp/models"
+	"myapp/services"
+	"myapp/utils"
+)
+
+// Codes returned with rejected taps so readers can show a specific message
+const (
+	TapCodeAlreadyCheckedIn   = "already_checked_in"
+	TapCodeAlreadyCheckedOut  = "already_checked_out"
+	TapCodeCheckoutNotOpen    = "checkout_not_open"
+	TapCodeMinDwellNotReached = "min_dwell_not_reached"
+	TapCodeWrongSchool        = "wrong_school"
+	TapCodeCardLost           = "card_lost"
+	TapCodeCardBlocked        = "card_blocked"
+	TapCodeCardRetired        = "card_retired"
+	TapCodeSignatureRequired  = "signature_required"
+	TapCodeInvalidSignature   = "invalid_signature"
+	TapCodeReplayedPayload    = "replayed_payload"
+	TapCodeSignedCardOffline  = "signed_card_offline"
+	TapCodeNoVisitorPass      = "no_visitor_pass"
+	TapCodePassNotYetValid    = "pass_not_yet_valid"
+	TapCodePassExpired        = "pass_expired"
+)
+
+// tapOrigin describes how a tap was made; it is recorded on the attendance
+type tapOrigin struct {
+	Source     string     // nfc, qr or manual
+	RecordedBy *uuid.UUID // teacher who entered a manual check-in
+	Reason     string     // why a manual check-in was needed
+}
+
+// errInvalidSchedule is returned when a school's schedule cannot be parsed
+var errInvalidSchedule = errors.New("invalid school schedule")
+
+// recordTap applies a tap made at now by student and returns the HTTP status
+// and response body for it. Taps from a registered device use the device's
+// location; taps submitted by a logged-in user pass location themselves.
+//
+// The first tap of the day (or session) is inserted as the check-in. The
+// unique index on attendances makes a concurrent tap lose that insert, after
+// which it locks the existing row and is evaluated as a checkout, so two
+// simultaneous taps always resolve to one check-in and one follow-up.
+// QR and manual check-ins go through the same path, marked by origin.
+//
+// A tap that belongs to a session also checks the student in for the day, so
+// a student attending only sessions is never marked absent.
+func recordTap(student models.Student, device *models.Device, location string, now time.Time, origin tapOrigin) (int, map[string]interface{}) {
+	var deviceID *uuid.UUID
+	if device != nil {
+		if device.SchoolID != student.SchoolID {
+			return http.StatusForbidden, map[string]interface{}{
+				"error": "Card belongs to a different school",
+				"code":  TapCodeWrongSchool,
+			}
+		}
+		deviceID = &device.ID
+		location = device.Location
+	}
+
+	// Get today's date in the student's school time zone
+	now = now.In(student.School.Location())
+	today := utils.LocalDate(now, now.Location())
+
+	// Resolve the school calendar; taps on non-school days are recorded but flagged
+	day, err := services.ResolveDay(config.DB, student.School, today)
+	if err != nil {
+		return http.StatusInternalServerError, map[string]interface{}{
+			"error": "Failed to check school calendar",
+		}
+	}
+
+	// Resolve which session the tap belongs to; nil means the daily check-in
+	session, err := services.ResolveSession(student.School, location, now)
+	if err != nil {
+		return http.StatusInternalServerError, map[string]interface{}{
+			"error": "Failed to resolve attendance session",
+		}
+	}
+
+	// The daily check-in uses the bell schedule
+	lateStart, lateGrace := day.Schedule.StartTime, day.Schedule.LateGraceMinutes
+	dailyStatus, err := checkInStatus(now, lateStart, lateGrace, day.IsSchoolDay)
+	if err != nil {
+		return http.StatusInternalServerError, map[string]interface{}{
+			"error": "Invalid school schedule",
+		}
+	}
+	checkoutOpen, err := utils.ClockOn(now, day.Schedule.CheckoutOpenTime)
+	if err != nil {
+		return http.StatusInternalServerError, map[string]interface{}{
+			"error": "Invalid school schedule",
+		}
+	}
+	dismissal, err := utils.ClockOn(now, day.Schedule.DismissalTime)
+	if err != nil {
+		return http.StatusInternalServerError, map[string]interface{}{
+			"error": "Invalid school schedule",
+		}
+	}
+
+	// Sessions use their own times; checkout opens shortly before the session ends
+	status := dailyStatus
+	if session != nil {
+		lateStart, lateGrace = session.StartTime, session.LateGraceMinutes
+		status, err = checkInStatus(now, lateStart, lateGrace, day.IsSchoolDay)
+		if err != nil {
+			return http.StatusInternalServerError, map[string]interface{}{
+				"error": "Invalid session schedule",
+			}
+		}
+		end, err := utils.ClockOn(now, session.EndTime)
+		if err != nil {
+			return http.StatusInternalServerError, map[string]interface{}{
+				"error": "Invalid session schedule",
+			}
+		}
+		checkoutOpen = end.Add(-services.SessionCheckoutWindow)
+	}
+
+	debounce := time.Duration(day.Schedule.DebounceSeconds) * time.Second
+	// The daily check-in requires a minimum time in school before checkout
+	minDwell := time.Duration(day.Schedule.MinDwellMinutes) * time.Minute
+	if session != nil {
+		minDwell = 0
+	}
+
+	var code int
+	var body map[string]interface{}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if session != nil {
+			if err := ensureDailyCheckIn(tx, student, today, now, dailyStatus, day, deviceID, origin); err != nil {
+				return err
+			}
+		}
+
+		// Try to insert the check-in
+		attendance := models.Attendance{
+			ID:           uuid.New(),
+			StudentID:    student.ID,
+			Date:         today,
+			TimeIn:       &now,
+			Status:       status,
+			NonSchoolDay: !day.IsSchoolDay,
+			DeviceID:     deviceID,
+			Source:       origin.Source,
+			ManualReason: origin.Reason,
+			RecordedBy:   origin.RecordedBy,
+		}
+		if session != nil {
+			attendance.SessionID = &session.ID
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&attendance)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			if err := services.OpenInterval(tx, attendance, now, deviceID, origin.Source); err != nil {
+				return err
+			}
+			code, body = http.StatusOK, checkInResponse(student, attendance, session, day)
+			return nil
+		}
+
+		// A record already exists; lock it so concurrent taps are evaluated one at a time
+		query := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("student_id = ? AND date = ?", student.ID, today)
+		if session != nil {
+			query = query.Where("session_id = ?", session.ID)
+		} else {
+			query = query.Where("session_id IS NULL")
+		}
+		if err := query.First(&attendance).Error; err != nil {
+			return err
+		}
+
+		// A voided record is replaced by this tap
+		if attendance.VoidedAt.Valid {
+			if err := services.ReviveAttendance(tx, &attendance); err != nil {
+				return err
+			}
+		}
+
+		// A record without TimeIn was created by the absence job or a leave
+		// request; a tap after the cutoff still counts as a check-in
+		if attendance.TimeIn == nil {
+			attendance.TimeIn = &now
+			attendance.Status = status
+			attendance.NonSchoolDay = !day.IsSchoolDay
+			attendance.DeviceID = deviceID
+			origin.applyCheckIn(&attendance)
+			if err := tx.Save(&attendance).Error; err != nil {
+				return err
+			}
+			if err := services.OpenInterval(tx, attendance, now, deviceID, origin.Source); err != nil {
+				return err
+			}
+			code, body = http.StatusOK, checkInResponse(student, attendance, session, day)
+			return nil
+		}
+
+		// A replayed offline tap older than the recorded check-in becomes the
+		// check-in. The recorded check-in then becomes the checkout if it
+		// would have been accepted as one; otherwise it was a repeat tap.
+		if now.Before(*attendance.TimeIn) {
+			later, laterDevice, laterSource := *attendance.TimeIn, attendance.DeviceID, attendance.Source
+			laterReason, laterRecordedBy := attendance.ManualReason, attendance.RecordedBy
+			attendance.TimeIn = &now
+			attendance.Status = status
+			attendance.DeviceID = deviceID
+			origin.applyCheckIn(&attendance)
+			if err := services.MoveFirstCheckIn(tx, attendance, now, deviceID, origin.Source); err != nil {
+				return err
+			}
+
+			checkout := attendance.TimeOut == nil &&
+				later.Sub(now) >= debounce &&
+				later.Sub(now) >= minDwell &&
+				!(day.IsSchoolDay && later.Before(checkoutOpen) && !day.Schedule.AllowIntervalExits)
+			if checkout {
+				attendance.TimeOut = &later
+				attendance.CheckoutDeviceID = laterDevice
+				attendance.CheckoutSource = laterSource
+				attendance.CheckoutReason = laterReason
+				attendance.CheckoutRecordedBy = laterRecordedBy
+				if err := services.CloseInterval(tx, &attendance, later, laterDevice, laterSource); err != nil {
+					return err
+				}
+				if session == nil && day.IsSchoolDay && later.Before(dismissal) {
+					attendance.Status = models.AttendanceStatusEarlyLeave
+				}
+			} else if attendance.TimeOut != nil {
+				if err := services.UpdatePresentMinutes(tx, &attendance); err != nil {
+					return err
+				}
+			}
+			if err := tx.Save(&attendance).Error; err != nil {
+				return err
+			}
+			code, body = http.StatusOK, checkInResponse(student, attendance, session, day)
+			return nil
+		}
+
+		// A tap after checkout is the student returning, unless it is a
+		// repeat of the checkout tap or a replayed tap from before it
+		if attendance.TimeOut != nil {
+			if now.Sub(*attendance.TimeOut) < debounce {
+				code, body = http.StatusBadRequest, map[string]interface{}{
+					"error": "Student already checked out",
+					"code":  TapCodeAlreadyCheckedOut,
+				}
+				return nil
+			}
+
+			if attendance.Status == models.AttendanceStatusEarlyLeave {
+				// The start time was parsed above, so this cannot fail
+				attendance.Status, _ = checkInStatus(*attendance.TimeIn, lateStart, lateGrace, day.IsSchoolDay)
+			}
+			// Records from before intervals existed keep their first stay
+			if err := services.BackfillInterval(tx, &attendance); err != nil {
+				return err
+			}
+			attendance.TimeOut = nil
+			attendance.CheckoutSource = ""
+			attendance.CheckoutReason = ""
+			attendance.CheckoutRecordedBy = nil
+			attendance.AutoCheckout = false
+			if err := tx.Save(&attendance).Error; err != nil {
+				return err
+			}
+			if err := services.OpenInterval(tx, attendance, now, deviceID, origin.Source); err != nil {
+				return err
+			}
+
+			code, body = http.StatusOK, checkInResponse(student, attendance, session, day)
+			body["message"] = "Re-entry successful"
+			body["time_in"] = now
+			body["returning"] = true
+			return nil
+		}
+
+		// Ignore accidental repeat taps right after check-in or re-entry
+		lastEntry, err := services.LastEntry(tx, attendance)
+		if err != nil {
+			return err
+		}
+		if now.Sub(lastEntry) < debounce {
+			code, body = http.StatusConflict, map[string]interface{}{
+				"error": "Student already checked in",
+				"code":  TapCodeAlreadyCheckedIn,
+			}
+			return nil
+		}
+
+		// Before checkout opens a tap is a temporary exit, e.g. for a
+		// doctor's visit, unless the school turned interval exits off;
+		// teachers may always check a student out early.
+		manual := origin.Source == models.AttendanceSourceManual
+		intervalExit := day.IsSchoolDay && now.Before(checkoutOpen)
+		if intervalExit && !manual && !day.Schedule.AllowIntervalExits {
+			code, body = http.StatusBadRequest, map[string]interface{}{
+				"error": "Checkout is not open yet",
+				"code":  TapCodeCheckoutNotOpen,
+			}
+			return nil
+		}
+
+		if !manual && now.Sub(lastEntry) < minDwell {
+			code, body = http.StatusConflict, map[string]interface{}{
+				"error": "Minimum time in school not reached",
+				"code":  TapCodeMinDwellNotReached,
+			}
+			return nil
+		}
+
+		attendance.TimeOut = &now
+		attendance.CheckoutDeviceID = deviceID
+		attendance.CheckoutSource = origin.Source
+		attendance.CheckoutReason = origin.Reason
+		attendance.CheckoutRecordedBy = origin.RecordedBy
+		if err := services.CloseInterval(tx, &attendance, now, deviceID, origin.Source); err != nil {
+			return err
+		}
+
+		// Leaving before dismissal is an early leave until the student returns
+		if session == nil && day.IsSchoolDay && now.Before(dismissal) {
+			attendance.Status = models.AttendanceStatusEarlyLeave
+		}
+
+		if err := tx.Save(&attendance).Error; err != nil {
+			return err
+		}
+
+		code, body = http.StatusOK, map[string]interface{}{
+			"message":         "Check-out successful",
+			"action":          models.TapOutcomeCheckOut,
+			"student":         student.Name,
+			"class":           student.Class,
+			"time_out":        attendance.TimeOut,
+			"status":          attendance.Status,
+			"present_minutes": attendance.PresentMinutes,
+			"interval_exit":   intervalExit,
+			"session":         session,
+			"attendance":      attendance,
+		}
+		return nil
+	})
+	if err != nil {
+		return http.StatusInternalServerError, map[string]interface{}{
+			"error": "Failed to record attendance",
+		}
+	}
+
+	return code, body
+}
+
+// ensureDailyCheckIn records the daily check-in for a tap that belongs to a
+// session. It only creates the record or fills one without a check-in, so the
+// student counts as present; checkouts stay with the session record.
+func ensureDailyCheckIn(tx *gorm.DB, student models.Student, today, now time.Time, status string, day services.DayInfo, deviceID *uuid.UUID, origin tapOrigin) error {
+	attendance := models.Attendance{
+		ID:           uuid.New(),
+		StudentID:    student.ID,
+		Date:         today,
+		TimeIn:       &now,
+		Status:       status,
+		NonSchoolDay: !day.IsSchoolDay,
+		DeviceID:     deviceID,
+		Source:       origin.Source,
+		ManualReason: origin.Reason,
+		RecordedBy:   origin.RecordedBy,
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&attendance)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 1 {
+		return services.OpenInterval(tx, attendance, now, deviceID, origin.Source)
+	}
+
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("student_id = ? AND date = ? AND session_id IS NULL", student.ID, today).
+		First(&attendance).Error
+	if err != nil {
+		return err
+	}
+	if attendance.VoidedAt.Valid {
+		if err := services.ReviveAttendance(tx, &attendance); err != nil {
+			return err
+		}
+	}
+	if attendance.TimeIn != nil {
+		return nil
+	}
+
+	attendance.TimeIn = &now
+	attendance.Status = status
+	attendance.NonSchoolDay = !day.IsSchoolDay
+	attendance.DeviceID = deviceID
+	origin.applyCheckIn(&attendance)
+	if err := tx.Save(&attendance).Error; err != nil {
+		return err
+	}
+	return services.OpenInterval(tx, attendance, now, deviceID, origin.Source)
+}
+
+// applyCheckIn records the origin of a check-in on an existing attendance
+func (o tapOrigin) applyCheckIn(attendance *models.Attendance) {
+	attendance.Source = o.Source
+	attendance.ManualReason = o.Reason
+	attendance.RecordedBy = o.RecordedBy
+}
+
+// checkInResponse builds the response body for a successful check-in
+func checkInResponse(student models.Student, attendance models.Attendance, session *models.AttendanceSession, day services.DayInfo) map[string]interface{} {
+	return map[string]interface{}{
+		"message":        "Check-in successful",
+		"action":         models.TapOutcomeCheckIn,
+		"student":        student.Name,
+		"class":          student.Class,
+		"time_in":        attendance.TimeIn,
+		"status":         attendance.Status,
+		"non_school_day": attendance.NonSchoolDay,
+		"reason":         day.Reason,
+		"session":        session,
+		"attendance":     attendance,
+	}
+}
+
+// newTapEvent starts the raw log entry for a tap
+func newTapEvent(c echo.Context, nfcUID string, device *models.Device, location string, now time.Time) models.TapEvent {
+	event := models.TapEvent{
+		ID:       uuid.New(),
+		NFCUID:   canonicalUID(nfcUID),
+		Location: location,
+		TappedAt: now,
+	}
+	if device != nil {
+		event.DeviceID = &device.ID
+		event.Location = device.Location
+	}
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		event.UserID = &userID
+	}
+	return event
+}
+
+// logTapEvent completes event from the tap's response and stores it.
+// Failures are only logged so a broken tap log never blocks attendance.
+func logTapEvent(event models.TapEvent, status int, body map[string]interface{}) {
+	fillTapEvent(&event, status, body)
+	if result := config.DB.Create(&event); result.Error != nil {
+		log.Println("Failed to store tap event:", result.Error)
+	}
+}
+
+// fillTapEvent sets the outcome, reason and attendance of event from the tap's response
+func fillTapEvent(event *models.TapEvent, status int, body map[string]interface{}) {
+	switch {
+	case event.StudentID == nil && event.StaffID == nil && event.VisitorPassID == nil:
+		event.Outcome = models.TapOutcomeUnknownCard
+	case status >= http.StatusInternalServerError:
+		event.Outcome = models.TapOutcomeError
+	default:
+		event.Outcome = models.TapOutcomeRejected
+	}
+	if action, ok := body["action"].(string); ok {
+		event.Outcome = action
+	}
+
+	if code, ok := body["code"].(string); ok {
+		event.Reason = code
+	} else if msg, ok := body["error"].(string); ok {
+		event.Reason = msg
+	}
+
+	if attendance, ok := body["attendance"].(models.Attendance); ok {
+		event.AttendanceID = &attendance.ID
+	}
+	if attendance, ok := body["attendance"].(models.StaffAttendance); ok {
+		event.AttendanceID = &attendance.ID
+	}
+}
+
+// lookupCard finds the card with nfcUID
+func lookupCard(nfcUID string) (models.NFCCard, error) {
+	var card models.NFCCard
+	err := config.DB.Where("uid = ?", canonicalUID(nfcUID)).First(&card).Error
+	return card, err
+}
+
+// findActiveStudent loads an active student with the school schedule
+// preloaded for recordTap
+func findActiveStudent(id uuid.UUID) (models.Student, error) {
+	var student models.Student
+	result := config.DB.Preload("School.Schedule.Overrides").
+		Where("id = ? AND is_active = ?", id, true).First(&student)
+	return student, result.Error
+}
+
+// processTap resolves the card of a tap and records it, setting the student
+// on event once the card is known. payload is the signed NDEF record read
+// from the card, required for cards with RequireSignature. Offline taps
+// replayed by a syncing reader are rejected for such cards: the reader can no
+// longer write next_payload to the card, so the card would keep a payload the
+// server has already consumed.
+func processTap(nfcUID, payload string, device *models.Device, location string, now time.Time, offline bool, event *models.TapEvent) (int, map[string]interface{}) {
+	card, err := lookupCard(nfcUID)
+	if err != nil {
+		// Reception pool cards are only valid through a visitor pass
+		if visitorCard, err := lookupVisitorCard(nfcUID); err == nil {
+			return processVisitorTap(visitorCard, device, now, event)
+		}
+		return http.StatusNotFound, map[string]interface{}{
+			"error": "Student not found or card not registered",
+		}
+	}
+
+	// Staff cards share the reader endpoint but are recorded separately
+	var student models.Student
+	var staff models.Staff
+	if card.StaffID != nil {
+		if staff, err = findActiveStaff(*card.StaffID); err != nil {
+			return http.StatusNotFound, map[string]interface{}{
+				"error": "Staff member not found or inactive",
+			}
+		}
+		event.StaffID = &staff.ID
+	} else {
+		if card.StudentID == nil {
+			return http.StatusNotFound, map[string]interface{}{
+				"error": "Student not found or card not registered",
+			}
+		}
+		if student, err = findActiveStudent(*card.StudentID); err != nil {
+			return http.StatusNotFound, map[string]interface{}{
+				"error": "Student not found or card not registered",
+			}
+		}
+		event.StudentID = &student.ID
+	}
+
+	switch card.Status {
+	case models.CardStatusActive:
+	case models.CardStatusLost:
+		return http.StatusForbidden, map[string]interface{}{
+			"error": "Card has been reported lost",
+			"code":  TapCodeCardLost,
+		}
+	case models.CardStatusBlocked:
+		return http.StatusForbidden, map[string]interface{}{
+			"error": "Card is blocked",
+			"code":  TapCodeCardBlocked,
+		}
+	default:
+		return http.StatusForbidden, map[string]interface{}{
+			"error": "Card is no longer valid",
+			"code":  TapCodeCardRetired,
+		}
+	}
+
+	var nextPayload string
+	if card.RequireSignature {
+		if offline {
+			return http.StatusForbidden, map[string]interface{}{
+				"error": "Signed cards cannot be recorded by offline sync",
+				"code":  TapCodeSignedCardOffline,
+			}
+		}
+		next, status, body := verifyCardPayload(card, payload)
+		if body != nil {
+			return status, body
+		}
+		nextPayload = next
+	}
+
+	origin := tapOrigin{Source: models.AttendanceSourceNFC}
+	if card.Type == models.CredentialTypeQRToken {
+		origin.Source = models.AttendanceSourceQR
+	}
+	event.Source = origin.Source
+
+	if card.StaffID != nil {
+		return recordStaffTap(staff, device, now, origin)
+	}
+
+	status, body := recordTap(student, device, location, now, origin)
+	if nextPayload != "" {
+		// The reader writes this back to the card so the next tap carries a
+		// higher counter
+		body["next_payload"] = nextPayload
+	}
+	return status, body
+}
+
+// verifyCardPayload checks the signed payload of a card and consumes its
+// counter, so the same payload is never accepted twice. Returns the payload
+// to write for the next tap, or the status and body of the rejection.
+func verifyCardPayload(card models.NFCCard, payload string) (string, int, map[string]interface{}) {
+	if payload == "" {
+		return "", http.StatusForbidden, map[string]interface{}{
+			"error": "Card requires a signed payload",
+			"code":  TapCodeSignatureRequired,
+		}
+	}
+
+	p, err := utils.VerifyCardPayload(payload)
+	if err == utils.ErrCardSigningDisabled {
+		return "", http.StatusInternalServerError, map[string]interface{}{
+			"error": "Card signing is not configured",
+		}
+	}
+	if err != nil || p.CardID != card.ID || card.StudentID == nil || p.StudentID != *card.StudentID {
+		return "", http.StatusForbidden, map[string]interface{}{
+			"error": "Invalid card signature",
+			"code":  TapCodeInvalidSignature,
+		}
+	}
+
+	result := config.DB.Model(&models.NFCCard{}).
+		Where("id = ? AND signature_counter < ?", card.ID, p.Counter).
+		Update("signature_counter", p.Counter)
+	if result.Error != nil {
+		return "", http.StatusInternalServerError, map[string]interface{}{
+			"error": "Failed to verify card",
+		}
+	}
+	if result.RowsAffected == 0 {
+		return "", http.StatusForbidden, map[string]interface{}{
+			"error": "Card payload has already been used",
+			"code":  TapCodeReplayedPayload,
+		}
+	}
+
+	p.Counter++
+	next, err := utils.SignCardPayload(p)
+	if err != nil {
+		return "", http.StatusInternalServerError, map[string]interface{}{
+			"error": "Failed to sign card payload",
+		}
+	}
+	return next, 0, nil
+}
+
+// canonicalUID normalizes uid when it is an NFC UID; other credentials such
+// as QR tokens are matched as sent
+func canonicalUID(uid string) string {
+	if normalized, err := utils.NormalizeUID(uid); err == nil {
+		return normalized
+	}
+	return uid
+}
+
+// checkInStatus returns "late" for a check-in at timeIn after start plus
+// graceMinutes on a school day, and "present" otherwise. start is "HH:MM"
+// on timeIn's day in timeIn's location, so timeIn must be in school time.
+func checkInStatus(timeIn time.Time, start string, graceMinutes int, schoolDay bool) (string, error) {
+	lateAfter, err := utils.ClockOn(timeIn, start)
+	if err != nil {
+		return "", err
+	}
+	if schoolDay && timeIn.After(lateAfter.Add(time.Duration(graceMinutes)*time.Minute)) {
+		return "late", nil
+	}
+	return "present", nil
+}
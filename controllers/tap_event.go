@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"myapp/config"
+	"myapp/models"
+)
+
+// Page size limits for tap event queries
+const (
+	defaultTapEventLimit = 100
+	maxTapEventLimit     = 500
+)
+
+type TapEventController struct{}
+
+// ListTapEvents queries the raw tap log.
+// Filters: device_id, student_id, visitor_pass_id, nfc_uid, outcome, from and to (RFC3339), limit, offset.
+func (tc *TapEventController) ListTapEvents(c echo.Context) error {
+	query := config.DB.Model(&models.TapEvent{})
+
+	for _, param := range []string{"device_id", "student_id", "visitor_pass_id"} {
+		value := c.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid " + param,
+			})
+		}
+		query = query.Where(param+" = ?", id)
+	}
+	if nfcUID := c.QueryParam("nfc_uid"); nfcUID != "" {
+		query = query.Where("nfc_uid = ?", canonicalUID(nfcUID))
+	}
+	if outcome := c.QueryParam("outcome"); outcome != "" {
+		query = query.Where("outcome = ?", outcome)
+	}
+	if from := c.QueryParam("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid from, expected RFC3339 timestamp",
+			})
+		}
+		query = query.Where("tapped_at >= ?", t)
+	}
+	if to := c.QueryParam("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid to, expected RFC3339 timestamp",
+			})
+		}
+		query = query.Where("tapped_at < ?", t)
+	}
+
+	limit, offset, msg := parsePage(c, defaultTapEventLimit, maxTapEventLimit)
+	if msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": msg,
+		})
+	}
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch tap events",
+		})
+	}
+
+	var events []models.TapEvent
+	result := query.Order("tapped_at DESC").Limit(limit).Offset(offset).Find(&events)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch tap events",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"tap_events": events,
+		"total":      total,
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// parsePage reads the limit and offset query parameters, capping limit at
+// maxLimit. Returns an error message if either is invalid.
+func parsePage(c echo.Context, defaultLimit, maxLimit int) (int, int, string) {
+	limit := defaultLimit
+	if value := c.QueryParam("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return 0, 0, "Invalid limit"
+		}
+		limit = min(n, maxLimit)
+	}
+	offset := 0
+	if value := c.QueryParam("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, 0, "Invalid offset"
+		}
+		offset = n
+	}
+	return limit, offset, ""
+}
@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"myapp/config"
+	"myapp/migrations"
+	"myapp/models"
+)
+
+// openTestDB connects config.DB to the database in TEST_DATABASE_DSN and
+// migrates it, skipping the test when no database is configured
+func openTestDB(t *testing.T) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := migrations.Prepare(db); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	err = db.AutoMigrate(
+		&models.User{},
+		&models.School{},
+		&models.Attendance{},
+		&models.Student{},
+		&models.SchoolSchedule{},
+		&models.ScheduleOverride{},
+		&models.CalendarEvent{},
+		&models.AcademicTerm{},
+		&models.AttendanceSession{},
+		&models.Device{},
+		&models.StaffShift{},
+		&models.Staff{},
+		&models.Class{},
+		&models.AttendanceInterval{},
+	)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	config.DB = db
+}
+
+// createTestStudent creates a school and an active student, removed again
+// when the test ends
+func createTestStudent(t *testing.T) models.Student {
+	t.Helper()
+
+	school := models.School{Name: "Test School " + uuid.NewString()}
+	if err := config.DB.Create(&school).Error; err != nil {
+		t.Fatalf("create school: %v", err)
+	}
+	student := models.Student{
+		NFCUID:    uuid.NewString(),
+		Name:      "Test Student",
+		Class:     "X IPA 1",
+		StudentID: uuid.NewString(),
+		SchoolID:  school.ID,
+		IsActive:  true,
+	}
+	if err := config.DB.Create(&student).Error; err != nil {
+		t.Fatalf("create student: %v", err)
+	}
+
+	t.Cleanup(func() {
+		config.DB.Exec("DELETE FROM attendance_intervals WHERE attendance_id IN (SELECT id FROM attendances WHERE student_id = ?)", student.ID)
+		config.DB.Exec("DELETE FROM attendances WHERE student_id = ?", student.ID)
+		config.DB.Exec("DELETE FROM students WHERE id = ?", student.ID)
+		config.DB.Exec("DELETE FROM schools WHERE id = ?", school.ID)
+	})
+
+	found, err := findActiveStudent(student.ID)
+	if err != nil {
+		t.Fatalf("load student: %v", err)
+	}
+	return found
+}
+
+func TestRecordTapConcurrentTapsCreateOneDailyRecord(t *testing.T) {
+	openTestDB(t)
+	student := createTestStudent(t)
+
+	// Weekday morning, after the default start time so no session or
+	// calendar setup is needed
+	now := time.Date(2026, 3, 4, 7, 0, 0, 0, student.School.Location())
+
+	const taps = 10
+	codes := make([]int, taps)
+	actions := make([]interface{}, taps)
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < taps; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			code, body := recordTap(student, nil, "", now, tapOrigin{Source: models.AttendanceSourceNFC})
+			codes[i], actions[i] = code, body["action"]
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	var count int64
+	config.DB.Model(&models.Attendance{}).
+		Where("student_id = ? AND session_id IS NULL", student.ID).
+		Count(&count)
+	if count != 1 {
+		t.Fatalf("daily records = %d, want 1", count)
+	}
+
+	checkIns := 0
+	for i := range codes {
+		switch {
+		case codes[i] == http.StatusOK && actions[i] == models.TapOutcomeCheckIn:
+			checkIns++
+		case codes[i] == http.StatusConflict:
+		default:
+			t.Errorf("tap %d: status %d, action %v", i, codes[i], actions[i])
+		}
+	}
+	if checkIns != 1 {
+		t.Errorf("check-ins = %d, want 1", checkIns)
+	}
+}
+
+// TestCheckInStatus covers the late decision recordTap makes at the grace
+// boundaries, in each Indonesian time zone
+func TestCheckInStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		zone      string
+		tap       time.Time // UTC instant of the tap
+		start     string
+		grace     int
+		schoolDay bool
+		want      string
+	}{
+		{"WIB exactly on time", "Asia/Jakarta", time.Date(2024, 7, 15, 0, 30, 0, 0, time.UTC), "07:30", 0, true, "present"},
+		{"WIB one second late", "Asia/Jakarta", time.Date(2024, 7, 15, 0, 30, 1, 0, time.UTC), "07:30", 0, true, "late"},
+		{"WIB exactly at grace", "Asia/Jakarta", time.Date(2024, 7, 15, 0, 40, 0, 0, time.UTC), "07:30", 10, true, "present"},
+		{"WIB grace plus one second", "Asia/Jakarta", time.Date(2024, 7, 15, 0, 40, 1, 0, time.UTC), "07:30", 10, true, "late"},
+		{"WITA exactly at grace", "Asia/Makassar", time.Date(2024, 7, 14, 23, 35, 0, 0, time.UTC), "07:30", 5, true, "present"},
+		{"WITA grace plus one second", "Asia/Makassar", time.Date(2024, 7, 14, 23, 35, 1, 0, time.UTC), "07:30", 5, true, "late"},
+		{"WIT exactly on time", "Asia/Jayapura", time.Date(2024, 7, 14, 22, 30, 0, 0, time.UTC), "07:30", 0, true, "present"},
+		{"WIT grace plus one second", "Asia/Jayapura", time.Date(2024, 7, 14, 22, 35, 1, 0, time.UTC), "07:30", 5, true, "late"},
+		// 07:30 WIB is 00:30 UTC; read in UTC the same tap would look early
+		{"WIB tap late in local time only", "Asia/Jakarta", time.Date(2024, 7, 15, 1, 0, 0, 0, time.UTC), "07:30", 0, true, "late"},
+		{"never late on a non-school day", "Asia/Jakarta", time.Date(2024, 7, 15, 5, 0, 0, 0, time.UTC), "07:30", 0, false, "present"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := time.LoadLocation(tt.zone)
+			if err != nil {
+				t.Fatalf("load %s: %v", tt.zone, err)
+			}
+			got, err := checkInStatus(tt.tap.In(loc), tt.start, tt.grace, tt.schoolDay)
+			if err != nil {
+				t.Fatalf("checkInStatus: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("checkInStatus = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := checkInStatus(time.Now(), "24:00", 0, true); err == nil {
+		t.Error("checkInStatus with an invalid start succeeded, want error")
+	}
+}
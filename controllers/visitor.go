@@ -0,0 +1,412 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"myapp/config"
+	"myapp/models"
+	"myapp/services"
+	"myapp/utils"
+)
+
+// maxVisitorPassDuration caps how long a visitor pass can stay valid
+const maxVisitorPassDuration = 7 * 24 * time.Hour
+
+var errVisitorCardInUse = errors.New("visitor card already issued")
+
+type VisitorController struct{}
+
+type VisitorCardRequest struct {
+	SchoolID uuid.UUID `json:"school_id" validate:"required"`
+	UID      string    `json:"uid" validate:"required"`
+	Label    string    `json:"label,omitempty"`
+}
+
+type UpdateVisitorCardRequest struct {
+	Label    string `json:"label,omitempty"`
+	IsActive *bool  `json:"is_active,omitempty"` // false takes a lost or damaged card out of the pool
+}
+
+type VisitorPassRequest struct {
+	CardUID        string     `json:"card_uid" validate:"required"` // UID of the pooled card handed out
+	VisitorName    string     `json:"visitor_name" validate:"required"`
+	IdentityNumber string     `json:"identity_number,omitempty"`
+	Phone          string     `json:"phone,omitempty"`
+	Host           string     `json:"host" validate:"required"`
+	Purpose        string     `json:"purpose" validate:"required"`
+	ValidFrom      *time.Time `json:"valid_from,omitempty"` // defaults to now
+	ValidUntil     time.Time  `json:"valid_until" validate:"required"`
+}
+
+// VisitorCardStatus is a pooled card with the pass it is currently issued on
+type VisitorCardStatus struct {
+	models.VisitorCard
+	ActivePass *models.VisitorPass `json:"active_pass"`
+}
+
+// ListVisitorCards lists the reception card pool of a school, with the
+// active pass of each card that is currently lent out
+func (vc *VisitorController) ListVisitorCards(c echo.Context) error {
+	query := config.DB.Order("label ASC, uid ASC")
+
+	if schoolID := c.QueryParam("school_id"); schoolID != "" {
+		id, err := uuid.Parse(schoolID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid school ID",
+			})
+		}
+		query = query.Where("school_id = ?", id)
+	}
+
+	var cards []models.VisitorCard
+	if result := query.Find(&cards); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch visitor cards",
+		})
+	}
+
+	cardIDs := make([]uuid.UUID, 0, len(cards))
+	for _, card := range cards {
+		cardIDs = append(cardIDs, card.ID)
+	}
+	var passes []models.VisitorPass
+	if len(cardIDs) > 0 {
+		result := config.DB.Where("card_id IN ? AND status = ?", cardIDs, models.VisitorPassActive).Find(&passes)
+		if result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to fetch visitor cards",
+			})
+		}
+	}
+	activePasses := make(map[uuid.UUID]models.VisitorPass, len(passes))
+	for _, pass := range passes {
+		activePasses[pass.CardID] = pass
+	}
+
+	statuses := make([]VisitorCardStatus, 0, len(cards))
+	available := 0
+	for _, card := range cards {
+		status := VisitorCardStatus{VisitorCard: card}
+		if pass, ok := activePasses[card.ID]; ok {
+			status.ActivePass = &pass
+		} else if card.IsActive {
+			available++
+		}
+		statuses = append(statuses, status)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"cards":     statuses,
+		"total":     len(statuses),
+		"available": available,
+	})
+}
+
+// CreateVisitorCard adds a card to a school's reception pool
+func (vc *VisitorController) CreateVisitorCard(c echo.Context) error {
+	req := new(VisitorCardRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	uid, err := utils.NormalizeUID(req.UID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid uid, expected a 4, 7 or 10 byte hex UID",
+		})
+	}
+
+	var school models.School
+	if result := config.DB.Where("id = ?", req.SchoolID).First(&school); result.Error != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "School not found",
+		})
+	}
+
+	card := models.VisitorCard{
+		ID:       uuid.New(),
+		SchoolID: req.SchoolID,
+		UID:      uid,
+		Label:    req.Label,
+		IsActive: true,
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkCardUID(tx, uid); err != nil {
+			return err
+		}
+		return tx.Create(&card).Error
+	})
+	if err != nil {
+		return credentialError(c, err, "Failed to create visitor card")
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Visitor card created successfully",
+		"card":    card,
+	})
+}
+
+// UpdateVisitorCard relabels a pooled card or takes it out of the pool
+func (vc *VisitorController) UpdateVisitorCard(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid card ID",
+		})
+	}
+
+	req := new(UpdateVisitorCardRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var card models.VisitorCard
+	if result := config.DB.Where("id = ?", id).First(&card); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Visitor card not found",
+		})
+	}
+
+	if req.Label != "" {
+		card.Label = req.Label
+	}
+	if req.IsActive != nil {
+		card.IsActive = *req.IsActive
+	}
+
+	if result := config.DB.Save(&card); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update visitor card",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Visitor card updated successfully",
+		"card":    card,
+	})
+}
+
+// ListVisitorPasses lists visitor passes.
+// Filters: school_id, card_id, status, inside (true/false), from and to (RFC3339, on valid_from).
+func (vc *VisitorController) ListVisitorPasses(c echo.Context) error {
+	query := config.DB.Model(&models.VisitorPass{})
+
+	for _, param := range []string{"school_id", "card_id"} {
+		value := c.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid " + param,
+			})
+		}
+		query = query.Where(param+" = ?", id)
+	}
+	if status := c.QueryParam("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if inside := c.QueryParam("inside"); inside != "" {
+		query = query.Where("inside = ?", inside == "true")
+	}
+	if from := c.QueryParam("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid from, expected RFC3339 timestamp",
+			})
+		}
+		query = query.Where("valid_from >= ?", t)
+	}
+	if to := c.QueryParam("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid to, expected RFC3339 timestamp",
+			})
+		}
+		query = query.Where("valid_from < ?", t)
+	}
+
+	var passes []models.VisitorPass
+	if result := query.Preload("Card").Order("valid_from DESC").Find(&passes); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch visitor passes",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"passes": passes,
+		"total":  len(passes),
+	})
+}
+
+// IssueVisitorPass lends a pooled card to a visitor. The card's entry and
+// exit taps on /attendance/record are recorded on the pass until it expires
+// or the card is returned.
+func (vc *VisitorController) IssueVisitorPass(c echo.Context) error {
+	req := new(VisitorPassRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.VisitorName == "" || req.Host == "" || req.Purpose == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "visitor_name, host and purpose are required",
+		})
+	}
+
+	now := time.Now()
+	validFrom := now
+	if req.ValidFrom != nil {
+		validFrom = *req.ValidFrom
+	}
+	if !req.ValidUntil.After(validFrom) || !req.ValidUntil.After(now) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "valid_until must be in the future and after valid_from",
+		})
+	}
+	if req.ValidUntil.Sub(validFrom) > maxVisitorPassDuration {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Visitor passes are valid for at most 7 days",
+		})
+	}
+
+	card, err := lookupVisitorCard(req.CardUID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Visitor card not found",
+		})
+	}
+	if !card.IsActive {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Visitor card is out of the pool",
+		})
+	}
+
+	// Free cards whose pass ran out since the scheduler last ran
+	if _, err := services.ExpireVisitorPasses(now); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to issue visitor pass",
+		})
+	}
+
+	pass := models.VisitorPass{
+		ID:             uuid.New(),
+		SchoolID:       card.SchoolID,
+		CardID:         card.ID,
+		VisitorName:    req.VisitorName,
+		IdentityNumber: req.IdentityNumber,
+		Phone:          req.Phone,
+		Host:           req.Host,
+		Purpose:        req.Purpose,
+		ValidFrom:      validFrom,
+		ValidUntil:     req.ValidUntil,
+		Status:         models.VisitorPassActive,
+	}
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		pass.IssuedBy = &userID
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the card so two receptionists cannot issue it at once
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", card.ID).First(&card).Error; err != nil {
+			return err
+		}
+		var active int64
+		if err := tx.Model(&models.VisitorPass{}).
+			Where("card_id = ? AND status = ?", card.ID, models.VisitorPassActive).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return errVisitorCardInUse
+		}
+		return tx.Create(&pass).Error
+	})
+	if err == errVisitorCardInUse {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Visitor card is already issued to another visitor",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to issue visitor pass",
+		})
+	}
+
+	pass.Card = &card
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Visitor pass issued successfully",
+		"pass":    pass,
+	})
+}
+
+// ReturnVisitorPass closes a pass when the card is handed back at reception,
+// making the card available again
+func (vc *VisitorController) ReturnVisitorPass(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid pass ID",
+		})
+	}
+
+	var pass models.VisitorPass
+	if result := config.DB.Where("id = ?", id).First(&pass); result.Error != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Visitor pass not found",
+		})
+	}
+	if pass.Status != models.VisitorPassActive {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Visitor pass is already closed",
+		})
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":    models.VisitorPassReturned,
+		"closed_at": now,
+		"inside":    false,
+	}
+	// Handing the card back means the visitor has left
+	if pass.Inside {
+		updates["exited_at"] = now
+	}
+
+	result := config.DB.Model(&models.VisitorPass{}).
+		Where("id = ? AND status = ?", pass.ID, models.VisitorPassActive).
+		Updates(updates)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to return visitor pass",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Visitor pass is already closed",
+		})
+	}
+
+	config.DB.Where("id = ?", pass.ID).First(&pass)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Visitor pass returned successfully",
+		"pass":    pass,
+	})
+}
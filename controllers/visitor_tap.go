@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"myapp/config"
+	"myapp/models"
+)
+
+// errPassClosed is returned when a pass expires or is returned while a tap
+// is being recorded
+var errPassClosed = errors.New("visitor pass closed")
+
+// lookupVisitorCard finds the pooled visitor card with nfcUID
+func lookupVisitorCard(nfcUID string) (models.VisitorCard, error) {
+	var card models.VisitorCard
+	err := config.DB.Where("uid = ?", canonicalUID(nfcUID)).First(&card).Error
+	return card, err
+}
+
+// processVisitorTap records a tap of a pooled visitor card on its active
+// pass. The first tap is the entry, the next the exit, and so on. Entry is
+// refused outside the pass's validity window, while a visitor still inside
+// can tap out until the scheduler expires the pass.
+func processVisitorTap(card models.VisitorCard, device *models.Device, now time.Time, event *models.TapEvent) (int, map[string]interface{}) {
+	if !card.IsActive {
+		return http.StatusForbidden, map[string]interface{}{
+			"error": "Card is blocked",
+			"code":  TapCodeCardBlocked,
+		}
+	}
+
+	var pass models.VisitorPass
+	result := config.DB.Where("card_id = ? AND status = ?", card.ID, models.VisitorPassActive).First(&pass)
+	if result.Error != nil {
+		return http.StatusForbidden, map[string]interface{}{
+			"error": "Card is not issued to a visitor",
+			"code":  TapCodeNoVisitorPass,
+		}
+	}
+	event.VisitorPassID = &pass.ID
+	event.Source = models.AttendanceSourceNFC
+
+	if device != nil && device.SchoolID != pass.SchoolID {
+		return http.StatusForbidden, map[string]interface{}{
+			"error": "Card belongs to a different school",
+			"code":  TapCodeWrongSchool,
+		}
+	}
+
+	var school models.School
+	if result := config.DB.Preload("Schedule.Overrides").Where("id = ?", pass.SchoolID).First(&school); result.Error != nil {
+		return http.StatusInternalServerError, map[string]interface{}{
+			"error": "Failed to record visit",
+		}
+	}
+	now = now.In(school.Location())
+	debounce := time.Duration(school.Schedule.ForWeekday(now.Weekday()).DebounceSeconds) * time.Second
+
+	var code int
+	var body map[string]interface{}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the pass so concurrent taps from two readers are evaluated one at a time
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", pass.ID).First(&pass).Error; err != nil {
+			return err
+		}
+		if pass.Status != models.VisitorPassActive {
+			return errPassClosed
+		}
+
+		// Ignore accidental repeat taps
+		if pass.LastTapAt != nil && now.Sub(*pass.LastTapAt) < debounce {
+			if pass.Inside {
+				code, body = http.StatusConflict, map[string]interface{}{
+					"error": "Visitor already entered",
+					"code":  TapCodeAlreadyCheckedIn,
+				}
+			} else {
+				code, body = http.StatusBadRequest, map[string]interface{}{
+					"error": "Visitor already left",
+					"code":  TapCodeAlreadyCheckedOut,
+				}
+			}
+			return nil
+		}
+
+		action := models.TapOutcomeCheckOut
+		if pass.Inside {
+			pass.Inside = false
+			pass.ExitedAt = &now
+		} else {
+			if now.Before(pass.ValidFrom) {
+				code, body = http.StatusForbidden, map[string]interface{}{
+					"error":      "Visitor pass is not valid yet",
+					"code":       TapCodePassNotYetValid,
+					"valid_from": pass.ValidFrom,
+				}
+				return nil
+			}
+			if !now.Before(pass.ValidUntil) {
+				code, body = http.StatusForbidden, map[string]interface{}{
+					"error":       "Visitor pass has expired",
+					"code":        TapCodePassExpired,
+					"valid_until": pass.ValidUntil,
+				}
+				return nil
+			}
+			action = models.TapOutcomeCheckIn
+			pass.Inside = true
+			if pass.EnteredAt == nil {
+				pass.EnteredAt = &now
+			}
+		}
+		pass.LastTapAt = &now
+		if err := tx.Save(&pass).Error; err != nil {
+			return err
+		}
+
+		message := "Visitor exit recorded"
+		if action == models.TapOutcomeCheckIn {
+			message = "Visitor entry recorded"
+		}
+		code, body = http.StatusOK, map[string]interface{}{
+			"message":     message,
+			"action":      action,
+			"visitor":     pass.VisitorName,
+			"host":        pass.Host,
+			"valid_until": pass.ValidUntil,
+			"pass":        pass,
+		}
+		return nil
+	})
+	if err == errPassClosed {
+		return http.StatusForbidden, map[string]interface{}{
+			"error": "Card is not issued to a visitor",
+			"code":  TapCodeNoVisitorPass,
+		}
+	}
+	if err != nil {
+		return http.StatusInternalServerError, map[string]interface{}{
+			"error": "Failed to record visit",
+		}
+	}
+
+	return code, body
+}
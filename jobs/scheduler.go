@@ -0,0 +1,232 @@
+package jobs
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"myapp/config"
+	"myapp/models"
+	"myapp/services"
+	"myapp/utils"
+)
+
+// defaultInterval is how often the scheduler wakes up to check cutoffs
+const defaultInterval = time.Minute
+
+// Start launches the in-process scheduler in a background goroutine.
+// The tick interval can be set with JOB_INTERVAL (e.g. "30s", "5m").
+func Start() {
+	interval := defaultInterval
+	if value := os.Getenv("JOB_INTERVAL"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			interval = d
+		} else {
+			log.Printf("Invalid JOB_INTERVAL %q, using %s", value, defaultInterval)
+		}
+	}
+
+	absence := &absenceJob{done: make(map[uuid.UUID]time.Time)}
+	checkout := &checkoutJob{}
+	staff := &staffJob{}
+	visitors := &visitorJob{}
+	cleanup := &cleanupJob{}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for now := range ticker.C {
+			absence.run(now)
+			checkout.run(now)
+			staff.run(now)
+			visitors.run(now)
+			cleanup.run(now)
+		}
+	}()
+
+	log.Printf("Scheduler started, checking every %s", interval)
+}
+
+// absenceJob marks students absent once a school's absent cutoff has passed
+type absenceJob struct {
+	// done remembers the last date processed per school so the job only
+	// queries each school once per day
+	done map[uuid.UUID]time.Time
+}
+
+func (j *absenceJob) run(now time.Time) {
+	var schools []models.School
+	result := config.DB.Preload("Schedule.Overrides").Where("is_active = ?", true).Find(&schools)
+	if result.Error != nil {
+		log.Println("Absence job: failed to load schools:", result.Error)
+		return
+	}
+
+	for _, school := range schools {
+		local := now.In(school.Location())
+		date := utils.LocalDate(local, local.Location())
+		if j.done[school.ID].Equal(date) {
+			continue
+		}
+
+		day, err := services.ResolveDay(config.DB, school, date)
+		if err != nil {
+			log.Printf("Absence job: failed to check calendar for school %s: %v", school.ID, err)
+			continue
+		}
+		if !day.IsSchoolDay {
+			j.done[school.ID] = date
+			continue
+		}
+
+		cutoff, err := utils.ClockOn(local, day.Schedule.AbsentCutoffTime)
+		if err != nil {
+			log.Printf("Absence job: invalid schedule for school %s: %v", school.ID, err)
+			continue
+		}
+		if local.Before(cutoff) {
+			continue
+		}
+
+		count, err := services.MarkAbsentees(school, date)
+		if err != nil {
+			log.Printf("Absence job: failed to mark absentees for school %s: %v", school.ID, err)
+			continue
+		}
+
+		j.done[school.ID] = date
+		log.Printf("Absence job: marked %d students absent for school %s on %s", count, school.Name, date.Format("2006-01-02"))
+	}
+}
+
+// checkoutInterval is how often open attendance is checked for auto checkout
+const checkoutInterval = 5 * time.Minute
+
+// checkoutJob closes attendance left open by students who never tapped out,
+// once the school's dismissal time (or the session's end) has passed
+type checkoutJob struct {
+	lastRun time.Time
+}
+
+func (j *checkoutJob) run(now time.Time) {
+	if now.Sub(j.lastRun) < checkoutInterval {
+		return
+	}
+	j.lastRun = now
+
+	var schools []models.School
+	result := config.DB.Preload("Schedule.Overrides").Where("is_active = ?", true).Find(&schools)
+	if result.Error != nil {
+		log.Println("Checkout job: failed to load schools:", result.Error)
+		return
+	}
+
+	for _, school := range schools {
+		// Earlier days are caught up as well
+		count, err := services.CloseOpenAttendance(school, now)
+		if err != nil {
+			log.Printf("Checkout job: failed to close open attendance for school %s: %v", school.ID, err)
+			continue
+		}
+
+		if count > 0 {
+			log.Printf("Checkout job: auto checked out %d attendances for school %s", count, school.Name)
+		}
+	}
+}
+
+// staffJob marks staff absent once their shift has ended without a tap and
+// closes staff attendance left open past the shift end
+type staffJob struct {
+	lastRun time.Time
+}
+
+func (j *staffJob) run(now time.Time) {
+	if now.Sub(j.lastRun) < checkoutInterval {
+		return
+	}
+	j.lastRun = now
+
+	var schools []models.School
+	result := config.DB.Preload("Schedule.Overrides").Where("is_active = ?", true).Find(&schools)
+	if result.Error != nil {
+		log.Println("Staff job: failed to load schools:", result.Error)
+		return
+	}
+
+	for _, school := range schools {
+		local := now.In(school.Location())
+		date := utils.LocalDate(local, local.Location())
+
+		absent, err := services.MarkStaffAbsentees(school, date, now)
+		if err != nil {
+			log.Printf("Staff job: failed to mark absentees for school %s: %v", school.ID, err)
+			continue
+		}
+		if absent > 0 {
+			log.Printf("Staff job: marked %d staff absent for school %s on %s", absent, school.Name, date.Format("2006-01-02"))
+		}
+
+		// Earlier days are caught up as well
+		closed, err := services.CloseOpenStaffAttendance(school, now)
+		if err != nil {
+			log.Printf("Staff job: failed to close open attendance for school %s: %v", school.ID, err)
+			continue
+		}
+		if closed > 0 {
+			log.Printf("Staff job: auto checked out %d staff attendances for school %s", closed, school.Name)
+		}
+	}
+}
+
+// visitorJob expires visitor passes once their validity window ends
+type visitorJob struct{}
+
+func (j *visitorJob) run(now time.Time) {
+	count, err := services.ExpireVisitorPasses(now)
+	if err != nil {
+		log.Println("Visitor job: failed to expire visitor passes:", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("Visitor job: expired %d visitor passes", count)
+	}
+}
+
+// cleanupInterval is how often expired records are pruned
+const cleanupInterval = time.Hour
+
+// cleanupJob prunes idempotency records past their replay window or left
+// pending by a crashed request, and used device nonces
+type cleanupJob struct {
+	lastRun time.Time
+}
+
+func (j *cleanupJob) run(now time.Time) {
+	if now.Sub(j.lastRun) < cleanupInterval {
+		return
+	}
+	j.lastRun = now
+
+	result := config.DB.
+		Where("created_at < ? OR (status_code = 0 AND created_at < ?)", now.Add(-models.IdempotencyWindow), now.Add(-models.IdempotencyPendingTimeout)).
+		Delete(&models.IdempotencyRecord{})
+	if result.Error != nil {
+		log.Println("Cleanup job: failed to prune idempotency records:", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Cleanup job: pruned %d idempotency records", result.RowsAffected)
+	}
+
+	result = config.DB.Where("created_at < ?", now.Add(-models.DeviceNonceRetention)).Delete(&models.DeviceNonce{})
+	if result.Error != nil {
+		log.Println("Cleanup job: failed to prune device nonces:", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Cleanup job: pruned %d device nonces", result.RowsAffected)
+	}
+}
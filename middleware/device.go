@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm/clause"
+	"myapp/config"
+	"myapp/models"
+)
+
+// Headers used by reader devices to authenticate
+const (
+	HeaderDeviceID        = "X-Device-ID"
+	HeaderDeviceTimestamp = "X-Device-Timestamp"
+	HeaderDeviceNonce     = "X-Device-Nonce"
+	HeaderDeviceSignature = "X-Device-Signature"
+)
+
+// maxDeviceClockSkew is how far a device timestamp may drift from server time
+const maxDeviceClockSkew = 5 * time.Minute
+
+// maxDeviceNonceLength bounds the size of device supplied nonces
+const maxDeviceNonceLength = 64
+
+// DeviceSignature computes the signature a device sends for a request:
+// hex(HMAC-SHA256(secret, method + "\n" + path + "\n" + timestamp + "\n" + nonce + "\n" + body)).
+// path is the request URI including the query string.
+func DeviceSignature(secret, method, path, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	for _, part := range []string{method, path, timestamp, nonce} {
+		mac.Write([]byte(part))
+		mac.Write([]byte("\n"))
+	}
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// DeviceAuthMiddleware authenticates a reader device by its HMAC-signed
+// request. Every request carries a fresh nonce; a nonce the device already
+// used is rejected so a captured request cannot be replayed.
+func DeviceAuthMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			deviceID, err := uuid.Parse(req.Header.Get(HeaderDeviceID))
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "Missing or invalid device ID",
+				})
+			}
+
+			timestamp := req.Header.Get(HeaderDeviceTimestamp)
+			unix, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "Missing or invalid device timestamp",
+				})
+			}
+			skew := time.Since(time.Unix(unix, 0))
+			if skew > maxDeviceClockSkew || skew < -maxDeviceClockSkew {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "Device timestamp outside allowed window",
+				})
+			}
+
+			nonce := req.Header.Get(HeaderDeviceNonce)
+			if nonce == "" || len(nonce) > maxDeviceNonceLength {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "Missing or invalid device nonce",
+				})
+			}
+
+			// Read the body for the signature and restore it for the handler
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "Invalid request body",
+				})
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			var device models.Device
+			result := config.DB.Where("id = ? AND is_enabled = ?", deviceID, true).First(&device)
+			if result.Error != nil {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "Unknown or disabled device",
+				})
+			}
+
+			expected := DeviceSignature(device.Secret, req.Method, req.URL.RequestURI(), timestamp, nonce, body)
+			if !hmac.Equal([]byte(expected), []byte(req.Header.Get(HeaderDeviceSignature))) {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "Invalid device signature",
+				})
+			}
+
+			used := models.DeviceNonce{DeviceID: device.ID, Nonce: nonce}
+			result = config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&used)
+			if result.Error != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to authenticate device",
+				})
+			}
+			if result.RowsAffected == 0 {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "Device request has already been used",
+				})
+			}
+
+			now := time.Now()
+			config.DB.Model(&device).Update("last_seen_at", now)
+			device.LastSeenAt = &now
+
+			// Store device info in context
+			c.Set("device", device)
+			c.Set("device_id", device.ID)
+			return next(c)
+		}
+	}
+}
+
+// DeviceOrJWTMiddleware accepts either a signed device request (when the
+// X-Device-ID header is present) or a user JWT
+func DeviceOrJWTMiddleware() echo.MiddlewareFunc {
+	deviceAuth := DeviceAuthMiddleware()
+	jwtAuth := JWTMiddleware()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		deviceNext := deviceAuth(next)
+		jwtNext := jwtAuth(next)
+		return func(c echo.Context) error {
+			if c.Request().Header.Get(HeaderDeviceID) != "" {
+				return deviceNext(c)
+			}
+			return jwtNext(c)
+		}
+	}
+}
+
+// DeviceOrStaffMiddleware accepts either a signed device request (when the
+// X-Device-ID header is present) or the JWT of a staff member or admin, for
+// endpoints only a gate reader or a staff scanner may call
+func DeviceOrStaffMiddleware() echo.MiddlewareFunc {
+	deviceAuth := DeviceAuthMiddleware()
+	jwtAuth := JWTMiddleware()
+	staffOnly := StaffOrAdminMiddleware()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		deviceNext := deviceAuth(next)
+		staffNext := jwtAuth(staffOnly(next))
+		return func(c echo.Context) error {
+			if c.Request().Header.Get(HeaderDeviceID) != "" {
+				return deviceNext(c)
+			}
+			return staffNext(c)
+		}
+	}
+}
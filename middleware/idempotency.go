@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm/clause"
+	"myapp/config"
+	"myapp/models"
+)
+
+// Headers used for idempotent requests
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotencyReplayed = "Idempotency-Replayed"
+)
+
+// maxIdempotencyKeyLength bounds the size of client supplied keys
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyMiddleware stores the first response to a request carrying an
+// Idempotency-Key header and replays it for repeats within
+// models.IdempotencyWindow. Keys are scoped to the calling device or user and
+// the route. Must run after the authentication middleware.
+func IdempotencyMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			key := req.Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				return next(c)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "Idempotency-Key is too long",
+				})
+			}
+
+			// Read the body for the fingerprint and restore it for the handler
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "Invalid request body",
+				})
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(body)
+			requestHash := hex.EncodeToString(sum[:])
+
+			scope := idempotencyScope(c)
+
+			// Drop an expired or abandoned record so the key can be claimed again
+			now := time.Now()
+			config.DB.Where("scope = ? AND key = ?", scope, key).
+				Where("created_at < ? OR (status_code = 0 AND created_at < ?)", now.Add(-models.IdempotencyWindow), now.Add(-models.IdempotencyPendingTimeout)).
+				Delete(&models.IdempotencyRecord{})
+
+			record := models.IdempotencyRecord{
+				ID:          uuid.New(),
+				Scope:       scope,
+				Key:         key,
+				RequestHash: requestHash,
+			}
+			result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+			if result.Error != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to store idempotency key",
+				})
+			}
+
+			if result.RowsAffected == 0 {
+				var existing models.IdempotencyRecord
+				if err := config.DB.Where("scope = ? AND key = ?", scope, key).First(&existing).Error; err != nil {
+					return c.JSON(http.StatusInternalServerError, map[string]string{
+						"error": "Failed to load idempotency key",
+					})
+				}
+				if existing.RequestHash != requestHash {
+					return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+						"error": "Idempotency-Key was already used with a different request body",
+					})
+				}
+				if existing.StatusCode == 0 {
+					return c.JSON(http.StatusConflict, map[string]string{
+						"error": "A request with this Idempotency-Key is still being processed",
+					})
+				}
+
+				c.Response().Header().Set(HeaderIdempotencyReplayed, "true")
+				return c.Blob(existing.StatusCode, echo.MIMEApplicationJSONCharsetUTF8, []byte(existing.ResponseBody))
+			}
+
+			// Release the key unless the response gets stored, including when
+			// the handler panics, so the client can retry
+			stored := false
+			defer func() {
+				if !stored {
+					config.DB.Delete(&record)
+				}
+			}()
+
+			// Capture the response written by the handler
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			// Server errors are not stored so the client can retry
+			status := c.Response().Status
+			if status >= http.StatusInternalServerError {
+				return nil
+			}
+
+			result = config.DB.Model(&record).Updates(map[string]interface{}{
+				"status_code":   status,
+				"response_body": recorder.body.String(),
+			})
+			stored = result.Error == nil
+			return nil
+		}
+	}
+}
+
+// idempotencyScope identifies the caller and route an idempotency key belongs to
+func idempotencyScope(c echo.Context) string {
+	caller := "anonymous"
+	if deviceID, ok := c.Get("device_id").(uuid.UUID); ok {
+		caller = "device:" + deviceID.String()
+	} else if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		caller = "user:" + userID.String()
+	}
+	return caller + " " + c.Request().Method + " " + c.Path()
+}
+
+// responseRecorder copies everything written to the response into body
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
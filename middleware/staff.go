@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"myapp/config"
+	"myapp/models"
+)
+
+// IsStaffOrAdmin reports whether the authenticated user is an admin or is
+// linked to an active staff member (teachers, homeroom teachers, reception).
+// Must run after JWTMiddleware.
+func IsStaffOrAdmin(c echo.Context) bool {
+	if role, ok := c.Get("user_role").(string); ok && (role == "admin" || role == "super_admin") {
+		return true
+	}
+
+	userID, ok := c.Get("user_id").(uuid.UUID)
+	if !ok {
+		return false
+	}
+
+	var count int64
+	config.DB.Model(&models.Staff{}).Where("user_id = ? AND is_active = ?", userID, true).Count(&count)
+	return count > 0
+}
+
+// StaffOrAdminMiddleware allows admins and users linked to an active staff member
+func StaffOrAdminMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Get("user_role") == nil {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "Unauthorized",
+				})
+			}
+
+			if !IsStaffOrAdmin(c) {
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error": "Access denied. Staff or admin role required",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package migrations
+
+import (
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+	"myapp/models"
+)
+
+// markSystemAttendanceSource sets the source of absence and leave rows made
+// before attendance had a source; they got the column default "nfc"
+func markSystemAttendanceSource(db *gorm.DB) error {
+	return db.Model(&models.Attendance{}).
+		Where("time_in IS NULL AND source = ?", models.AttendanceSourceNFC).
+		Update("source", models.AttendanceSourceSystem).Error
+}
+
+// attendanceDuplicate is an attendance row that repeats the student, date and
+// session of the row kept in its place
+type attendanceDuplicate struct {
+	ID     string
+	KeepID string
+}
+
+// dedupeAttendances removes duplicate daily and session attendances so the
+// unique indexes idx_attendance_student_date and
+// idx_attendance_student_session can be built. The row with the earliest
+// check-in is kept; tap events and audits of the removed rows are moved to it,
+// and so is the latest checkout when a removed row holds it, which is how the
+// check-in/check-out race split one visit across two rows.
+func dedupeAttendances(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable("attendances") {
+		return nil
+	}
+
+	partition := "student_id, date"
+	if migrator.HasColumn("attendances", "session_id") {
+		partition += ", session_id"
+	}
+
+	var duplicates []attendanceDuplicate
+	err := db.Raw(`SELECT id, keep_id FROM (
+		SELECT id, FIRST_VALUE(id) OVER w AS keep_id, ROW_NUMBER() OVER w AS n
+		FROM attendances
+		WINDOW w AS (PARTITION BY ` + partition + ` ORDER BY time_in ASC NULLS LAST, created_at, id)
+	) ranked WHERE n > 1`).Scan(&duplicates).Error
+	if err != nil || len(duplicates) == 0 {
+		return err
+	}
+
+	var moved []string
+	for _, table := range []string{"tap_events", "attendance_audits"} {
+		if migrator.HasTable(table) {
+			moved = append(moved, table)
+		}
+	}
+	hasIntervals := migrator.HasTable("attendance_intervals")
+
+	// Checkout fields copied along with time_out, as far as the schema has them
+	var checkoutColumns []string
+	for _, column := range []string{"status", "checkout_source", "checkout_device_id", "checkout_reason", "checkout_recorded_by", "auto_checkout", "present_minutes"} {
+		if migrator.HasColumn("attendances", column) {
+			checkoutColumns = append(checkoutColumns, column)
+		}
+	}
+
+	groups := make(map[string][]string)
+	for _, d := range duplicates {
+		groups[d.KeepID] = append(groups[d.KeepID], d.ID)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for keepID, ids := range groups {
+			merged, err := mergeCheckout(tx, keepID, ids, checkoutColumns)
+			if err != nil {
+				return err
+			}
+			// The kept intervals no longer match the merged checkout; they
+			// are rebuilt from time in and time out on the next re-entry
+			if merged && hasIntervals {
+				if err := tx.Exec("DELETE FROM attendance_intervals WHERE attendance_id = ?", keepID).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, d := range duplicates {
+			for _, table := range moved {
+				if err := tx.Exec("UPDATE "+table+" SET attendance_id = ? WHERE attendance_id = ?", d.KeepID, d.ID).Error; err != nil {
+					return err
+				}
+			}
+			if hasIntervals {
+				if err := tx.Exec("DELETE FROM attendance_intervals WHERE attendance_id = ?", d.ID).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Exec("DELETE FROM attendances WHERE id = ?", d.ID).Error; err != nil {
+				return err
+			}
+		}
+		log.Printf("Removed %d duplicate attendances", len(duplicates))
+		return nil
+	})
+}
+
+// mergeCheckout copies the latest checkout among the kept row and its
+// duplicates onto the kept row, with the given checkout columns. Status is
+// only taken over when the checkout made it an early leave. Reports whether
+// the kept row changed.
+func mergeCheckout(tx *gorm.DB, keepID string, duplicateIDs []string, columns []string) (bool, error) {
+	sets := []string{"time_out = latest.time_out"}
+	for _, column := range columns {
+		if column == "status" {
+			sets = append(sets, "status = CASE WHEN latest.status = '"+models.AttendanceStatusEarlyLeave+"' THEN latest.status ELSE kept.status END")
+			continue
+		}
+		sets = append(sets, column+" = latest."+column)
+	}
+
+	ids := append([]string{keepID}, duplicateIDs...)
+	result := tx.Exec(`UPDATE attendances AS kept SET `+strings.Join(sets, ", ")+`
+		FROM (
+			SELECT * FROM attendances WHERE id IN ? AND time_out IS NOT NULL
+			ORDER BY time_out DESC LIMIT 1
+		) AS latest
+		WHERE kept.id = ? AND latest.id <> kept.id
+		AND (kept.time_out IS NULL OR latest.time_out > kept.time_out)`, ids, keepID)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,132 @@
+package migrations
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"myapp/models"
+)
+
+// openTestDB connects to the database in TEST_DATABASE_DSN and migrates it,
+// skipping the test when no database is configured
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := Prepare(db); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	err = db.AutoMigrate(
+		&models.User{},
+		&models.School{},
+		&models.Student{},
+		&models.AttendanceSession{},
+		&models.Attendance{},
+		&models.AttendanceInterval{},
+	)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestDedupeAttendancesKeepsCheckoutOfDuplicate(t *testing.T) {
+	db := openTestDB(t)
+
+	school := models.School{Name: "Test School " + uuid.NewString()}
+	if err := db.Create(&school).Error; err != nil {
+		t.Fatalf("create school: %v", err)
+	}
+	student := models.Student{
+		NFCUID:    uuid.NewString(),
+		Name:      "Test Student",
+		Class:     "X IPA 1",
+		StudentID: uuid.NewString(),
+		SchoolID:  school.ID,
+		IsActive:  true,
+	}
+	if err := db.Create(&student).Error; err != nil {
+		t.Fatalf("create student: %v", err)
+	}
+
+	// The race this cleans up predates the unique index, so drop it to
+	// recreate the duplicates and restore it afterwards
+	if err := db.Migrator().DropIndex(&models.Attendance{}, "idx_attendance_student_date"); err != nil {
+		t.Fatalf("drop index: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM attendance_intervals WHERE attendance_id IN (SELECT id FROM attendances WHERE student_id = ?)", student.ID)
+		db.Exec("DELETE FROM attendances WHERE student_id = ?", student.ID)
+		db.Exec("DELETE FROM students WHERE id = ?", student.ID)
+		db.Exec("DELETE FROM schools WHERE id = ?", school.ID)
+		if err := db.Migrator().CreateIndex(&models.Attendance{}, "idx_attendance_student_date"); err != nil {
+			t.Errorf("restore index: %v", err)
+		}
+	})
+
+	date := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	checkIn := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	secondIn := checkIn.Add(time.Second)
+	checkOut := time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC)
+
+	kept := models.Attendance{StudentID: student.ID, Date: date, TimeIn: &checkIn, Status: "present"}
+	duplicate := models.Attendance{
+		StudentID:      student.ID,
+		Date:           date,
+		TimeIn:         &secondIn,
+		TimeOut:        &checkOut,
+		Status:         "present",
+		CheckoutSource: models.AttendanceSourceNFC,
+		PresentMinutes: 480,
+	}
+	for _, attendance := range []*models.Attendance{&kept, &duplicate} {
+		if err := db.Create(attendance).Error; err != nil {
+			t.Fatalf("create attendance: %v", err)
+		}
+	}
+	interval := models.AttendanceInterval{AttendanceID: kept.ID, TimeIn: checkIn}
+	if err := db.Create(&interval).Error; err != nil {
+		t.Fatalf("create interval: %v", err)
+	}
+
+	if err := dedupeAttendances(db); err != nil {
+		t.Fatalf("dedupe: %v", err)
+	}
+
+	var remaining []models.Attendance
+	db.Where("student_id = ?", student.ID).Find(&remaining)
+	if len(remaining) != 1 {
+		t.Fatalf("attendances = %d, want 1", len(remaining))
+	}
+	got := remaining[0]
+	if got.ID != kept.ID {
+		t.Errorf("kept %s, want the earliest check-in %s", got.ID, kept.ID)
+	}
+	if got.TimeIn == nil || !got.TimeIn.Equal(checkIn) {
+		t.Errorf("time_in = %v, want %v", got.TimeIn, checkIn)
+	}
+	if got.TimeOut == nil || !got.TimeOut.Equal(checkOut) {
+		t.Errorf("time_out = %v, want %v", got.TimeOut, checkOut)
+	}
+	if got.CheckoutSource != models.AttendanceSourceNFC || got.PresentMinutes != 480 {
+		t.Errorf("checkout_source = %q, present_minutes = %d, want %q, 480", got.CheckoutSource, got.PresentMinutes, models.AttendanceSourceNFC)
+	}
+
+	var intervals int64
+	db.Model(&models.AttendanceInterval{}).Where("attendance_id = ?", kept.ID).Count(&intervals)
+	if intervals != 0 {
+		t.Errorf("intervals = %d, want 0 so they are rebuilt from time in and out", intervals)
+	}
+}
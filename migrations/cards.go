@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"myapp/models"
+)
+
+// backfillNFCCards creates an active NFCCard for every student whose
+// Student.NFCUID has no card record yet (students registered before cards
+// had a lifecycle)
+func backfillNFCCards(db *gorm.DB) error {
+	var students []models.Student
+	result := db.
+		Where("nfc_uid <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM nfc_cards WHERE nfc_cards.uid = students.nfc_uid)").
+		Find(&students)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, student := range students {
+		card := models.NFCCard{
+			ID:        uuid.New(),
+			UID:       student.NFCUID,
+			StudentID: &student.ID,
+			Status:    models.CardStatusActive,
+			IssuedAt:  student.CreatedAt,
+		}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&card).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"myapp/models"
+	"myapp/utils"
+)
+
+// linkStudentClasses converts the free-text Student.Class of students without
+// a ClassID into Class records of the current academic year. Spellings that
+// normalize to the same name ("XII IPA 1", "12 IPA 1") share one class, and
+// Student.Class is rewritten to the canonical name.
+func linkStudentClasses(db *gorm.DB) error {
+	var students []models.Student
+	result := db.Preload("School").Where("class_id IS NULL AND class <> ''").Find(&students)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	now := time.Now()
+	for _, student := range students {
+		year := utils.AcademicYear(now.In(student.School.Location()))
+		name, grade := utils.NormalizeClassName(student.Class)
+		if name == "" {
+			continue
+		}
+		class, err := findOrCreateClass(db, student.SchoolID, year, name, grade)
+		if err != nil {
+			return err
+		}
+
+		err = db.Model(&models.Student{}).Where("id = ?", student.ID).
+			Updates(map[string]interface{}{"class_id": class.ID, "class": class.Name}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findOrCreateClass returns the class of the school and academic year with
+// the normalized name, creating it if needed. Only the migration creates
+// classes implicitly; registration requires an existing class.
+func findOrCreateClass(db *gorm.DB, schoolID uuid.UUID, academicYear, name string, grade int) (models.Class, error) {
+	class := models.Class{
+		ID:           uuid.New(),
+		SchoolID:     schoolID,
+		AcademicYear: academicYear,
+		Name:         name,
+		GradeLevel:   grade,
+		IsActive:     true,
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&class).Error; err != nil {
+		return models.Class{}, err
+	}
+
+	err := db.Where("school_id = ? AND academic_year = ? AND name = ?", schoolID, academicYear, name).First(&class).Error
+	return class, err
+}
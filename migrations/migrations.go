@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// step is an idempotent data migration run after the schema auto-migration
+type step struct {
+	name string
+	run  func(db *gorm.DB) error
+}
+
+// steps run in order on every startup, so each must be safe to re-run
+var steps = []step{
+	{"check nfc uids", checkNFCUIDs},
+	{"backfill nfc cards", backfillNFCCards},
+	{"mark system attendance source", markSystemAttendanceSource},
+	{"link student classes", linkStudentClasses},
+	{"split staff early leave", splitStaffEarlyLeave},
+}
+
+// prepareSteps run on every startup before the schema auto-migration, to fix
+// data that would make a new constraint fail
+var prepareSteps = []step{
+	{"dedupe attendances", dedupeAttendances},
+	{"drop global client tap index", dropGlobalClientTapIndex},
+}
+
+// Prepare applies the steps that must run before the schema auto-migration
+func Prepare(db *gorm.DB) error {
+	return runSteps(db, prepareSteps)
+}
+
+// Run applies all data migrations
+func Run(db *gorm.DB) error {
+	return runSteps(db, steps)
+}
+
+// runSteps applies steps in order
+func runSteps(db *gorm.DB, steps []step) error {
+	for _, s := range steps {
+		if err := s.run(db); err != nil {
+			return fmt.Errorf("%s: %w", s.name, err)
+		}
+		log.Printf("Data migration %q done", s.name)
+	}
+	return nil
+}
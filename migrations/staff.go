@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"myapp/models"
+	"myapp/utils"
+)
+
+// splitStaffEarlyLeave moves early leave out of the status of staff
+// attendance recorded before it became a separate flag. The check-in status
+// the early leave overwrote is recomputed from the shift.
+func splitStaffEarlyLeave(db *gorm.DB) error {
+	var attendances []models.StaffAttendance
+	result := db.Preload("Staff.School").Preload("Shift").
+		Where("status = ?", models.AttendanceStatusEarlyLeave).
+		Find(&attendances)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, attendance := range attendances {
+		status := "present"
+		if attendance.TimeIn != nil && attendance.Shift != nil && attendance.Staff != nil {
+			timeIn := attendance.TimeIn.In(attendance.Staff.School.Location())
+			start, err := utils.ClockOn(timeIn, attendance.Shift.StartTime)
+			if err == nil && timeIn.After(start.Add(time.Duration(attendance.Shift.LateGraceMinutes)*time.Minute)) {
+				status = "late"
+			}
+		}
+
+		err := db.Model(&attendance).Updates(map[string]interface{}{
+			"status":      status,
+			"late":        status == "late",
+			"early_leave": true,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
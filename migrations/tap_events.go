@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// globalClientTapIndex is the unique index on tap_events.client_tap_id alone,
+// replaced by idx_tap_event_client_tap on (device_id, client_tap_id)
+const globalClientTapIndex = "idx_tap_events_client_tap_id"
+
+// dropGlobalClientTapIndex drops the old unique index on client tap IDs so two
+// readers may use the same ID for their own buffered taps
+func dropGlobalClientTapIndex(db *gorm.DB) error {
+	if !db.Migrator().HasIndex("tap_events", globalClientTapIndex) {
+		return nil
+	}
+	return db.Migrator().DropIndex("tap_events", globalClientTapIndex)
+}
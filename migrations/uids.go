@@ -0,0 +1,161 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+	"myapp/models"
+	"myapp/utils"
+)
+
+// UIDCollision lists stored UIDs that normalize to the same canonical UID.
+// They are left unchanged and need to be resolved by hand.
+type UIDCollision struct {
+	Table string   `json:"table"`
+	UID   string   `json:"uid"`
+	Raw   []string `json:"raw"`
+}
+
+// UIDReport summarizes a NormalizeUIDs run
+type UIDReport struct {
+	Normalized int            `json:"normalized"` // rows rewritten (or to be rewritten on a dry run)
+	TapEvents  int64          `json:"tap_events"` // tap log entries rewritten (or to be rewritten on a dry run)
+	Invalid    []string       `json:"invalid"`    // stored values that are not valid NFC UIDs
+	Collisions []UIDCollision `json:"collisions"`
+}
+
+// uidRow is a stored UID and the row holding it
+type uidRow struct {
+	ID  string
+	UID string
+}
+
+// NormalizeUIDs rewrites nfc_cards.uid, students.nfc_uid and
+// tap_events.nfc_uid into the canonical form of utils.NormalizeUID. QR tokens
+// are skipped; invalid values and card values that would collide after
+// normalization are reported and left as they are. With apply false nothing
+// is written.
+func NormalizeUIDs(db *gorm.DB, apply bool) (UIDReport, error) {
+	var report UIDReport
+
+	var cards []uidRow
+	if err := db.Model(&models.NFCCard{}).Select("id", "uid").
+		Where("type <> ?", models.CredentialTypeQRToken).Scan(&cards).Error; err != nil {
+		return report, err
+	}
+
+	var students []uidRow
+	if err := db.Model(&models.Student{}).Select("id", "nfc_uid AS uid").
+		Where("nfc_uid <> ''").Scan(&students).Error; err != nil {
+		return report, err
+	}
+
+	var tapUIDs []string
+	if err := db.Model(&models.TapEvent{}).Distinct("nfc_uid").
+		Where("nfc_uid <> ''").Pluck("nfc_uid", &tapUIDs).Error; err != nil {
+		return report, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := normalizeColumn(tx, &report, "nfc_cards", "uid", cards, apply); err != nil {
+			return err
+		}
+		if err := normalizeColumn(tx, &report, "students", "nfc_uid", students, apply); err != nil {
+			return err
+		}
+		return normalizeTapEvents(tx, &report, tapUIDs, apply)
+	})
+	return report, err
+}
+
+// normalizeTapEvents normalizes the UIDs logged on tap events. The log may
+// hold the same UID many times, so there are no collisions; invalid values
+// (e.g. unknown cards) are kept as they are.
+func normalizeTapEvents(tx *gorm.DB, report *UIDReport, uids []string, apply bool) error {
+	for _, raw := range uids {
+		uid, err := utils.NormalizeUID(raw)
+		if err != nil || uid == raw {
+			continue
+		}
+
+		query := tx.Model(&models.TapEvent{}).Where("nfc_uid = ?", raw)
+		if !apply {
+			var count int64
+			if err := query.Count(&count).Error; err != nil {
+				return err
+			}
+			report.TapEvents += count
+			continue
+		}
+		result := query.Update("nfc_uid", uid)
+		if result.Error != nil {
+			return result.Error
+		}
+		report.TapEvents += result.RowsAffected
+	}
+	return nil
+}
+
+// normalizeColumn normalizes the UIDs of one table column
+func normalizeColumn(tx *gorm.DB, report *UIDReport, table, column string, rows []uidRow, apply bool) error {
+	groups := make(map[string][]uidRow)
+	var order []string
+	for _, row := range rows {
+		uid, err := utils.NormalizeUID(row.UID)
+		if err != nil {
+			report.Invalid = append(report.Invalid, table+": "+row.UID)
+			continue
+		}
+		if _, ok := groups[uid]; !ok {
+			order = append(order, uid)
+		}
+		groups[uid] = append(groups[uid], row)
+	}
+
+	for _, uid := range order {
+		group := groups[uid]
+		if len(group) > 1 {
+			collision := UIDCollision{Table: table, UID: uid}
+			for _, row := range group {
+				collision.Raw = append(collision.Raw, row.UID)
+			}
+			report.Collisions = append(report.Collisions, collision)
+			continue
+		}
+
+		row := group[0]
+		if row.UID == uid {
+			continue
+		}
+		report.Normalized++
+		if !apply {
+			continue
+		}
+		if err := tx.Table(table).Where("id = ?", row.ID).Update(column, uid).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkNFCUIDs is the startup step of NormalizeUIDs. It only does a dry run
+// and warns about UIDs that still need cmd/normalize-uids, which stays the
+// only place that rewrites them.
+func checkNFCUIDs(db *gorm.DB) error {
+	report, err := NormalizeUIDs(db, false)
+	if err != nil {
+		return err
+	}
+	if report.Normalized > 0 || report.TapEvents > 0 {
+		log.Printf("Warning: %d NFC UIDs and %d tap events are not in canonical form and will not match taps, run cmd/normalize-uids -apply",
+			report.Normalized, report.TapEvents)
+	}
+	for _, c := range report.Collisions {
+		log.Printf("NFC UID collision in %s: %v all normalize to %s", c.Table, c.Raw, c.UID)
+	}
+	if len(report.Invalid) > 0 {
+		log.Printf("%d stored NFC UIDs are not valid, run cmd/normalize-uids for details", len(report.Invalid))
+	}
+	return nil
+}
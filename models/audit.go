@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Attendance audit actions
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionVoid   = "void"
+)
+
+// AttendanceAudit model records every manual change to an attendance row.
+// Entries are kept after the attendance is voided.
+type AttendanceAudit struct {
+	ID           uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	AttendanceID uuid.UUID       `json:"attendance_id" gorm:"type:uuid;not null;index"`
+	Action       string          `json:"action" gorm:"not null"` // create, update, void
+	UserID       uuid.UUID       `json:"user_id" gorm:"type:uuid;not null"`
+	User         *User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Reason       string          `json:"reason" gorm:"not null"`
+	Before       json.RawMessage `json:"before" gorm:"type:jsonb"` // null for create
+	After        json.RawMessage `json:"after" gorm:"type:jsonb"`  // null for void
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// BeforeCreate hook for AttendanceAudit
+func (a *AttendanceAudit) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Calendar event types
+const (
+	CalendarEventHoliday = "holiday"
+	CalendarEventHalfDay = "half_day"
+)
+
+// CalendarEvent model marks holidays and special half-days.
+// A nil SchoolID applies the event to every school (e.g. national holidays).
+// Dates are calendar dates stored as midnight UTC, EndDate is inclusive.
+type CalendarEvent struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SchoolID         *uuid.UUID `json:"school_id" gorm:"type:uuid;index"`
+	Name             string     `json:"name" gorm:"not null"`
+	Type             string     `json:"type" gorm:"not null"` // holiday, half_day
+	StartDate        time.Time  `json:"start_date" gorm:"not null;index"`
+	EndDate          time.Time  `json:"end_date" gorm:"not null;index"`
+	CheckoutOpenTime *string    `json:"checkout_open_time"` // half_day only, replaces the schedule's checkout open time
+	DismissalTime    *string    `json:"dismissal_time"`     // half_day only, replaces the schedule's dismissal time
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate hook for CalendarEvent
+func (e *CalendarEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// AcademicTerm model defines a term of the academic year.
+// When a school has terms, days outside every term are not school days.
+type AcademicTerm struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SchoolID  uuid.UUID `json:"school_id" gorm:"type:uuid;not null;index"`
+	Name      string    `json:"name" gorm:"not null"` // e.g. "2024/2025 Ganjil"
+	StartDate time.Time `json:"start_date" gorm:"not null"`
+	EndDate   time.Time `json:"end_date" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeCreate hook for AcademicTerm
+func (t *AcademicTerm) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NFC card statuses
+const (
+	CardStatusActive  = "active"
+	CardStatusLost    = "lost"
+	CardStatusBlocked = "blocked"
+	CardStatusRetired = "retired"
+)
+
+// Credential types a card record can represent
+const (
+	CredentialTypeNFCCard = "nfc_card"
+	CredentialTypeNFCTag  = "nfc_tag"  // NFC sticker, e.g. on a phone
+	CredentialTypeQRToken = "qr_token" // token encoded in a printed or on-screen QR code
+)
+
+// NFCCard model keeps the history of credentials issued to a student or a
+// staff member; exactly one of StudentID and StaffID is set.
+// A student may hold several active credentials, up to the school's
+// MaxActiveCredentials. Only active credentials are accepted by
+// RecordAttendance; Student.NFCUID mirrors the student's primary card.
+// Cards with RequireSignature must also present a signed NDEF payload whose
+// counter increases on every tap, so a cloned UID or replayed read is rejected.
+type NFCCard struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UID              string     `json:"uid" gorm:"uniqueIndex;not null"`
+	Type             string     `json:"type" gorm:"not null;default:'nfc_card'"` // nfc_card, nfc_tag, qr_token
+	StudentID        *uuid.UUID `json:"student_id" gorm:"type:uuid;index"`       // set for student credentials
+	Student          *Student   `json:"student,omitempty" gorm:"foreignKey:StudentID"`
+	StaffID          *uuid.UUID `json:"staff_id" gorm:"type:uuid;index"` // set for staff credentials
+	Staff            *Staff     `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+	Status           string     `json:"status" gorm:"not null;default:'active';index"` // active, lost, blocked, retired
+	IssuedAt         time.Time  `json:"issued_at" gorm:"not null"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	RevokedReason    string     `json:"revoked_reason"`
+	RequireSignature bool       `json:"require_signature" gorm:"not null;default:false"` // taps must carry a signed NDEF payload
+	SignatureCounter int64      `json:"signature_counter" gorm:"not null;default:0"`     // counter of the last accepted payload
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate hook for NFCCard
+func (n *NFCCard) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	if n.Type == "" {
+		n.Type = CredentialTypeNFCCard
+	}
+	return nil
+}
+
+// IsCredentialType reports whether t is a known credential type
+func IsCredentialType(t string) bool {
+	switch t {
+	case CredentialTypeNFCCard, CredentialTypeNFCTag, CredentialTypeQRToken:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Class model is a homeroom class of a school in one academic year, e.g.
+// "XII IPA 1" in 2025/2026. Names are stored in the canonical form of
+// utils.NormalizeClassName so the same class is never recorded twice.
+type Class struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SchoolID          uuid.UUID  `json:"school_id" gorm:"type:uuid;not null;uniqueIndex:idx_class_school_year_name"`
+	AcademicYear      string     `json:"academic_year" gorm:"not null;uniqueIndex:idx_class_school_year_name"` // e.g. "2025/2026"
+	Name              string     `json:"name" gorm:"not null;uniqueIndex:idx_class_school_year_name"`
+	GradeLevel        int        `json:"grade_level" gorm:"not null;default:0"` // 1-12, 0 when the name has no grade
+	HomeroomTeacherID *uuid.UUID `json:"homeroom_teacher_id" gorm:"type:uuid"`
+	HomeroomTeacher   *Staff     `json:"homeroom_teacher,omitempty" gorm:"foreignKey:HomeroomTeacherID"`
+	IsActive          bool       `json:"is_active" gorm:"default:true"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate hook for Class
+func (c *Class) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
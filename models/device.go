@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Device model for unattended NFC readers.
+// Readers authenticate by signing requests with Secret (see DeviceAuthMiddleware).
+type Device struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SchoolID   uuid.UUID  `json:"school_id" gorm:"type:uuid;not null;index"`
+	School     School     `json:"school" gorm:"foreignKey:SchoolID"`
+	Name       string     `json:"name" gorm:"not null"`
+	Location   string     `json:"location"` // e.g. "main-gate", matched against AttendanceSession.Location
+	Secret     string     `json:"-" gorm:"not null"`
+	IsEnabled  bool       `json:"is_enabled" gorm:"default:true"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate hook for Device
+func (d *Device) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DeviceNonceRetention is how long used device nonces are kept. It covers the
+// clock skew allowed on either side of a device timestamp, so a request
+// cannot be replayed while its timestamp is still accepted.
+const DeviceNonceRetention = 15 * time.Minute
+
+// DeviceNonce records a nonce a reader device has used in a signed request
+type DeviceNonce struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	DeviceID  uuid.UUID `json:"device_id" gorm:"type:uuid;not null;uniqueIndex:idx_device_nonce"`
+	Nonce     string    `json:"nonce" gorm:"not null;uniqueIndex:idx_device_nonce"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// BeforeCreate hook for DeviceNonce
+func (n *DeviceNonce) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IdempotencyWindow is how long a stored response is replayed for a repeated key
+const IdempotencyWindow = 24 * time.Hour
+
+// IdempotencyPendingTimeout is how long a record may stay pending before it is
+// treated as abandoned (e.g. the server crashed mid-request) and the key can
+// be claimed again
+const IdempotencyPendingTimeout = time.Minute
+
+// IdempotencyRecord model stores the first response to a request sent with an
+// Idempotency-Key header so retries get the same answer.
+// A StatusCode of 0 means the first request is still being processed.
+type IdempotencyRecord struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Scope        string    `json:"scope" gorm:"not null;uniqueIndex:idx_idempotency_scope_key"` // caller and route, e.g. "device:<id> POST /api/v1/attendance/record"
+	Key          string    `json:"key" gorm:"not null;uniqueIndex:idx_idempotency_scope_key"`
+	RequestHash  string    `json:"request_hash" gorm:"not null"`
+	StatusCode   int       `json:"status_code" gorm:"not null;default:0"`
+	ResponseBody string    `json:"response_body" gorm:"type:text"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// BeforeCreate hook for IdempotencyRecord
+func (r *IdempotencyRecord) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AttendanceInterval model is one stretch of presence within an attendance
+// record. A student who leaves and returns on the same day gets one interval
+// per visit; the attendance's TimeIn and TimeOut span all of them.
+type AttendanceInterval struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	AttendanceID     uuid.UUID  `json:"attendance_id" gorm:"type:uuid;not null;index"`
+	TimeIn           time.Time  `json:"time_in" gorm:"not null"`
+	TimeOut          *time.Time `json:"time_out"` // nil while the student is present
+	DeviceID         *uuid.UUID `json:"device_id" gorm:"type:uuid"`
+	CheckoutDeviceID *uuid.UUID `json:"checkout_device_id" gorm:"type:uuid"`
+	Source           string     `json:"source" gorm:"not null;default:'nfc'"` // nfc, qr, manual
+	CheckoutSource   string     `json:"checkout_source"`                      // nfc, qr, manual or system
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate hook for AttendanceInterval
+func (i *AttendanceInterval) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	if i.Source == "" {
+		i.Source = AttendanceSourceNFC
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Leave request types, also used as the resulting Attendance status
+const (
+	LeaveTypeExcused = "excused" // izin
+	LeaveTypeSick    = "sick"    // sakit
+)
+
+// Leave request states
+const (
+	LeaveStatePending  = "pending"
+	LeaveStateApproved = "approved"
+	LeaveStateRejected = "rejected"
+)
+
+// LeaveRequest model for excused (izin) and sick (sakit) absences.
+// Dates are calendar dates stored as midnight UTC, EndDate is inclusive.
+type LeaveRequest struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	StudentID     uuid.UUID  `json:"student_id" gorm:"type:uuid;not null;index"`
+	Student       Student    `json:"student" gorm:"foreignKey:StudentID"`
+	StartDate     time.Time  `json:"start_date" gorm:"not null"`
+	EndDate       time.Time  `json:"end_date" gorm:"not null"`
+	Type          string     `json:"type" gorm:"not null"` // excused, sick
+	Reason        string     `json:"reason" gorm:"not null"`
+	AttachmentRef string     `json:"attachment_ref"`                                // e.g. URL or storage key of a doctor's note
+	State         string     `json:"state" gorm:"not null;default:'pending';index"` // pending, approved, rejected
+	SubmittedBy   uuid.UUID  `json:"submitted_by" gorm:"type:uuid;not null"`
+	ReviewedBy    *uuid.UUID `json:"reviewed_by" gorm:"type:uuid"`
+	ReviewedAt    *time.Time `json:"reviewed_at"`
+	ReviewNote    string     `json:"review_note"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate hook for LeaveRequest
+func (l *LeaveRequest) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Default bell schedule used when a school has not configured its own
+const (
+	DefaultStartTime          = "07:30"
+	DefaultLateGraceMinutes   = 0
+	DefaultCheckoutOpenTime   = "12:00"
+	DefaultAbsentCutoffTime   = "10:00"
+	DefaultDismissalTime      = "15:00"
+	DefaultDebounceSeconds    = 60
+	DefaultMinDwellMinutes    = 0
+	DefaultAllowIntervalExits = true
+	DefaultSchoolDays         = "1,2,3,4,5"
+)
+
+// SchoolSchedule model holds the bell schedule of a school.
+// Times are stored as "HH:MM" in the school's local time.
+type SchoolSchedule struct {
+	ID                 uuid.UUID          `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SchoolID           uuid.UUID          `json:"school_id" gorm:"type:uuid;uniqueIndex;not null"`
+	StartTime          string             `json:"start_time" gorm:"not null;default:'07:30'"`
+	LateGraceMinutes   int                `json:"late_grace_minutes" gorm:"not null;default:0"`
+	CheckoutOpenTime   string             `json:"checkout_open_time" gorm:"not null;default:'12:00'"`
+	AbsentCutoffTime   string             `json:"absent_cutoff_time" gorm:"not null;default:'10:00'"` // students without a tap by this time are marked absent
+	DismissalTime      string             `json:"dismissal_time" gorm:"not null;default:'15:00'"`     // open attendance is closed at this time by the auto-checkout job
+	DebounceSeconds    int                `json:"debounce_seconds" gorm:"not null;default:60"`        // repeat taps within this window are ignored
+	MinDwellMinutes    int                `json:"min_dwell_minutes" gorm:"not null;default:0"`        // minimum time after check-in before checkout is accepted
+	SchoolDays         string             `json:"school_days" gorm:"not null;default:'1,2,3,4,5'"`    // comma separated weekdays with classes, 0 = Sunday ... 6 = Saturday
+	AllowIntervalExits bool               `json:"allow_interval_exits" gorm:"not null;default:true"`  // a tap before checkout open time is a temporary exit instead of being rejected
+	Overrides          []ScheduleOverride `json:"overrides" gorm:"foreignKey:ScheduleID;constraint:OnDelete:CASCADE"`
+	CreatedAt          time.Time          `json:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at"`
+}
+
+// BeforeCreate hook for SchoolSchedule
+func (s *SchoolSchedule) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// ScheduleOverride replaces parts of the schedule on a specific weekday.
+// Nil fields fall back to the base schedule.
+type ScheduleOverride struct {
+	ID               uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ScheduleID       uuid.UUID `json:"schedule_id" gorm:"type:uuid;not null;uniqueIndex:idx_schedule_weekday"`
+	Weekday          int       `json:"weekday" gorm:"not null;uniqueIndex:idx_schedule_weekday"` // 0 = Sunday ... 6 = Saturday
+	StartTime        *string   `json:"start_time"`
+	LateGraceMinutes *int      `json:"late_grace_minutes"`
+	CheckoutOpenTime *string   `json:"checkout_open_time"`
+	AbsentCutoffTime *string   `json:"absent_cutoff_time"`
+	DismissalTime    *string   `json:"dismissal_time"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// BeforeCreate hook for ScheduleOverride
+func (o *ScheduleOverride) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+// DaySchedule is the effective schedule for a single day
+type DaySchedule struct {
+	StartTime          string `json:"start_time"`
+	LateGraceMinutes   int    `json:"late_grace_minutes"`
+	CheckoutOpenTime   string `json:"checkout_open_time"`
+	AbsentCutoffTime   string `json:"absent_cutoff_time"`
+	DismissalTime      string `json:"dismissal_time"`
+	DebounceSeconds    int    `json:"debounce_seconds"`
+	MinDwellMinutes    int    `json:"min_dwell_minutes"`
+	AllowIntervalExits bool   `json:"allow_interval_exits"`
+}
+
+// DefaultDaySchedule returns the schedule used by schools without one
+func DefaultDaySchedule() DaySchedule {
+	return DaySchedule{
+		StartTime:          DefaultStartTime,
+		LateGraceMinutes:   DefaultLateGraceMinutes,
+		CheckoutOpenTime:   DefaultCheckoutOpenTime,
+		AbsentCutoffTime:   DefaultAbsentCutoffTime,
+		DismissalTime:      DefaultDismissalTime,
+		DebounceSeconds:    DefaultDebounceSeconds,
+		MinDwellMinutes:    DefaultMinDwellMinutes,
+		AllowIntervalExits: DefaultAllowIntervalExits,
+	}
+}
+
+// OnSchoolDay reports whether the school holds classes on weekday.
+// Schools without a schedule are open Monday to Friday.
+func (s *SchoolSchedule) OnSchoolDay(weekday time.Weekday) bool {
+	if s == nil {
+		return weekdayListed(DefaultSchoolDays, weekday)
+	}
+	return weekdayListed(s.SchoolDays, weekday)
+}
+
+// weekdayListed reports whether weekday is in a comma separated weekday list
+func weekdayListed(weekdays string, weekday time.Weekday) bool {
+	for _, part := range strings.Split(weekdays, ",") {
+		day, err := strconv.Atoi(strings.TrimSpace(part))
+		if err == nil && day == int(weekday) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForWeekday resolves the schedule for a weekday, applying any override
+func (s *SchoolSchedule) ForWeekday(weekday time.Weekday) DaySchedule {
+	if s == nil {
+		return DefaultDaySchedule()
+	}
+
+	day := DaySchedule{
+		StartTime:          s.StartTime,
+		LateGraceMinutes:   s.LateGraceMinutes,
+		CheckoutOpenTime:   s.CheckoutOpenTime,
+		AbsentCutoffTime:   s.AbsentCutoffTime,
+		DismissalTime:      s.DismissalTime,
+		DebounceSeconds:    s.DebounceSeconds,
+		MinDwellMinutes:    s.MinDwellMinutes,
+		AllowIntervalExits: s.AllowIntervalExits,
+	}
+
+	for _, o := range s.Overrides {
+		if o.Weekday != int(weekday) {
+			continue
+		}
+		if o.StartTime != nil {
+			day.StartTime = *o.StartTime
+		}
+		if o.LateGraceMinutes != nil {
+			day.LateGraceMinutes = *o.LateGraceMinutes
+		}
+		if o.CheckoutOpenTime != nil {
+			day.CheckoutOpenTime = *o.CheckoutOpenTime
+		}
+		if o.AbsentCutoffTime != nil {
+			day.AbsentCutoffTime = *o.AbsentCutoffTime
+		}
+		if o.DismissalTime != nil {
+			day.DismissalTime = *o.DismissalTime
+		}
+	}
+
+	return day
+}
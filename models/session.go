@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Attendance session types
+const (
+	SessionTypePeriod          = "period"
+	SessionTypeExtracurricular = "extracurricular"
+	SessionTypeExam            = "exam"
+)
+
+// AttendanceSession model defines a period, extracurricular or exam that is
+// attended separately from the daily gate check-in.
+// Times are stored as "HH:MM" in the school's local time.
+type AttendanceSession struct {
+	ID               uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SchoolID         uuid.UUID `json:"school_id" gorm:"type:uuid;not null;index"`
+	Name             string    `json:"name" gorm:"not null"`
+	Type             string    `json:"type" gorm:"not null;default:'period'"` // period, extracurricular, exam
+	StartTime        string    `json:"start_time" gorm:"not null"`
+	EndTime          string    `json:"end_time" gorm:"not null"`
+	LateGraceMinutes int       `json:"late_grace_minutes" gorm:"not null;default:0"`
+	Weekdays         string    `json:"weekdays" gorm:"not null;default:'1,2,3,4,5'"` // comma separated, 0 = Sunday ... 6 = Saturday
+	Location         string    `json:"location"`                                     // reader location the session is bound to, empty for any reader
+	IsActive         bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// BeforeCreate hook for AttendanceSession
+func (s *AttendanceSession) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// OnWeekday reports whether the session takes place on weekday
+func (s *AttendanceSession) OnWeekday(weekday time.Weekday) bool {
+	return weekdayListed(s.Weekdays, weekday)
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Staff model for teachers and other employees who tap in at the same
+// readers as students. A staff member may be linked to a login User.
+type Staff struct {
+	ID         uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     *uuid.UUID  `json:"user_id" gorm:"type:uuid;uniqueIndex"`
+	User       *User       `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	SchoolID   uuid.UUID   `json:"school_id" gorm:"type:uuid;not null;index"`
+	School     School      `json:"school" gorm:"foreignKey:SchoolID"`
+	Name       string      `json:"name" gorm:"not null"`
+	EmployeeID string      `json:"employee_id" gorm:"uniqueIndex;not null"`
+	Position   string      `json:"position"` // e.g. teacher, security, admin staff
+	ShiftID    *uuid.UUID  `json:"shift_id" gorm:"type:uuid"`
+	Shift      *StaffShift `json:"shift,omitempty" gorm:"foreignKey:ShiftID"`
+	IsActive   bool        `json:"is_active" gorm:"default:true"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// BeforeCreate hook for Staff
+func (s *Staff) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// StaffShift model is the working hours of a group of staff.
+// Times are stored as "HH:MM" in the school's local time.
+type StaffShift struct {
+	ID               uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SchoolID         uuid.UUID `json:"school_id" gorm:"type:uuid;not null;index"`
+	Name             string    `json:"name" gorm:"not null"`
+	StartTime        string    `json:"start_time" gorm:"not null"`
+	EndTime          string    `json:"end_time" gorm:"not null"` // checkout before this time is an early leave
+	LateGraceMinutes int       `json:"late_grace_minutes" gorm:"not null;default:0"`
+	Weekdays         string    `json:"weekdays" gorm:"not null;default:'1,2,3,4,5'"` // comma separated, 0 = Sunday ... 6 = Saturday
+	IsActive         bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// BeforeCreate hook for StaffShift
+func (s *StaffShift) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// OnWeekday reports whether the shift is worked on weekday
+func (s *StaffShift) OnWeekday(weekday time.Weekday) bool {
+	return weekdayListed(s.Weekdays, weekday)
+}
+
+// StaffStatusAbsent marks a staff record created for a shift without a tap
+const StaffStatusAbsent = "absent"
+
+// StaffAttendance model. A staff member has at most one record per date.
+type StaffAttendance struct {
+	ID               uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	StaffID          uuid.UUID   `json:"staff_id" gorm:"type:uuid;not null;uniqueIndex:idx_staff_attendance_date"`
+	Staff            *Staff      `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+	Date             time.Time   `json:"date" gorm:"not null;uniqueIndex:idx_staff_attendance_date"`
+	ShiftID          *uuid.UUID  `json:"shift_id" gorm:"type:uuid"` // shift in effect on the date, nil for a day off
+	Shift            *StaffShift `json:"shift,omitempty" gorm:"foreignKey:ShiftID"`
+	TimeIn           *time.Time  `json:"time_in"`
+	TimeOut          *time.Time  `json:"time_out"`
+	Status           string      `json:"status" gorm:"not null;default:'present'"`    // check-in status: present, late, absent
+	Late             bool        `json:"late" gorm:"not null;default:false"`          // checked in after the shift start plus grace
+	EarlyLeave       bool        `json:"early_leave" gorm:"not null;default:false"`   // checked out before the shift end
+	AutoCheckout     bool        `json:"auto_checkout" gorm:"not null;default:false"` // TimeOut was inferred by the auto-checkout job, not tapped
+	DeviceID         *uuid.UUID  `json:"device_id" gorm:"type:uuid"`
+	CheckoutDeviceID *uuid.UUID  `json:"checkout_device_id" gorm:"type:uuid"`
+	Source           string      `json:"source" gorm:"not null;default:'nfc'"` // nfc, qr
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+}
+
+// BeforeCreate hook for StaffAttendance
+func (a *StaffAttendance) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	if a.Source == "" {
+		a.Source = AttendanceSourceNFC
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Tap event outcomes
+const (
+	TapOutcomeCheckIn     = "check_in"
+	TapOutcomeCheckOut    = "check_out"
+	TapOutcomeRejected    = "rejected"
+	TapOutcomeUnknownCard = "unknown_card"
+	TapOutcomeError       = "error"
+	TapOutcomePending     = "pending" // claimed by an offline sync that is still being processed
+)
+
+// TapEvent model is the raw log of every tap received, including rejected
+// ones, kept separately from the derived Attendance rows
+type TapEvent struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ClientTapID   *string    `json:"client_tap_id" gorm:"uniqueIndex:idx_tap_event_client_tap"` // idempotency ID sent by readers syncing buffered taps, unique per device
+	NFCUID        string     `json:"nfc_uid" gorm:"not null;index"`                             // empty for QR and manual check-ins
+	Source        string     `json:"source" gorm:"not null;default:'nfc'"`                      // nfc, qr, manual
+	DeviceID      *uuid.UUID `json:"device_id" gorm:"type:uuid;index;uniqueIndex:idx_tap_event_client_tap"`
+	UserID        *uuid.UUID `json:"user_id" gorm:"type:uuid"` // set when a logged-in user submitted the tap
+	StudentID     *uuid.UUID `json:"student_id" gorm:"type:uuid;index"`
+	StaffID       *uuid.UUID `json:"staff_id" gorm:"type:uuid;index"`        // set instead of StudentID for staff cards
+	VisitorPassID *uuid.UUID `json:"visitor_pass_id" gorm:"type:uuid;index"` // set instead of StudentID for visitor cards
+	AttendanceID  *uuid.UUID `json:"attendance_id" gorm:"type:uuid"`         // Attendance or StaffAttendance the tap recorded
+	Location      string     `json:"location"`
+	TappedAt      time.Time  `json:"tapped_at" gorm:"not null;index"`
+	Outcome       string     `json:"outcome" gorm:"not null;index"` // check_in, check_out, rejected, unknown_card, error
+	Reason        string     `json:"reason"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// BeforeCreate hook for TapEvent
+func (t *TapEvent) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	if t.Source == "" {
+		t.Source = AttendanceSourceNFC
+	}
+	return nil
+}
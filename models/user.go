@@ -2,6 +2,7 @@ package models
 
 import (
 	"time"
+	_ "time/tzdata" // embed zone database so school time zones resolve on minimal images
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -28,16 +29,23 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 
 // Student model for NFC attendance
 type Student struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	NFCUID    string    `json:"nfc_uid" gorm:"uniqueIndex;not null"`
-	Name      string    `json:"name" gorm:"not null"`
-	Class     string    `json:"class" gorm:"not null"`
-	StudentID string    `json:"student_id" gorm:"uniqueIndex;not null"`
-	SchoolID  uuid.UUID `json:"school_id" gorm:"type:uuid;not null"`
-	School    School    `json:"school" gorm:"foreignKey:SchoolID"`
-	IsActive  bool      `json:"is_active" gorm:"default:true"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	NFCUID    string     `json:"nfc_uid" gorm:"uniqueIndex;not null"` // primary card, see NFCCard for all credentials
+	Name      string     `json:"name" gorm:"not null"`
+	Class     string     `json:"class" gorm:"not null"` // name of Classroom, kept for display
+	ClassID   *uuid.UUID `json:"class_id" gorm:"type:uuid;index"`
+	Classroom *Class     `json:"classroom,omitempty" gorm:"foreignKey:ClassID"`
+	StudentID string     `json:"student_id" gorm:"uniqueIndex;not null"`
+	SchoolID  uuid.UUID  `json:"school_id" gorm:"type:uuid;not null"`
+	School    School     `json:"school" gorm:"foreignKey:SchoolID"`
+	IsActive  bool       `json:"is_active" gorm:"default:true"`
+	// GuardianUserID is the parent/guardian account allowed to submit leave requests
+	GuardianUserID *uuid.UUID `json:"guardian_user_id" gorm:"type:uuid;index"`
+	// UserID is the student's own account, allowed to fetch their QR token
+	UserID    *uuid.UUID `json:"user_id" gorm:"type:uuid;uniqueIndex"`
+	QRSecret  string     `json:"-"` // key of the student's rotating QR token, generated on first use
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
 }
 
 // BeforeCreate hook for Student
@@ -48,16 +56,26 @@ func (s *Student) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// DefaultTimezone is used for schools without a configured time zone
+const DefaultTimezone = "Asia/Jakarta"
+
+// DefaultMaxActiveCredentials is the number of active cards, tags and QR
+// tokens a student may hold unless the school configures otherwise
+const DefaultMaxActiveCredentials = 2
+
 // School model
 type School struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Name      string    `json:"name" gorm:"not null"`
-	Address   string    `json:"address"`
-	Phone     string    `json:"phone"`
-	Email     string    `json:"email"`
-	IsActive  bool      `json:"is_active" gorm:"default:true"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                   uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name                 string          `json:"name" gorm:"not null"`
+	Address              string          `json:"address"`
+	Phone                string          `json:"phone"`
+	Email                string          `json:"email"`
+	Timezone             string          `json:"timezone" gorm:"not null;default:'Asia/Jakarta'"`  // IANA zone, e.g. Asia/Jakarta, Asia/Makassar, Asia/Jayapura
+	MaxActiveCredentials int             `json:"max_active_credentials" gorm:"not null;default:2"` // active cards, tags and QR tokens allowed per student
+	IsActive             bool            `json:"is_active" gorm:"default:true"`
+	Schedule             *SchoolSchedule `json:"schedule,omitempty" gorm:"foreignKey:SchoolID"`
+	CreatedAt            time.Time       `json:"created_at"`
+	UpdatedAt            time.Time       `json:"updated_at"`
 }
 
 // BeforeCreate hook for School
@@ -65,20 +83,78 @@ func (s *School) BeforeCreate(tx *gorm.DB) error {
 	if s.ID == uuid.Nil {
 		s.ID = uuid.New()
 	}
+	if s.Timezone == "" {
+		s.Timezone = DefaultTimezone
+	}
+	if s.MaxActiveCredentials == 0 {
+		s.MaxActiveCredentials = DefaultMaxActiveCredentials
+	}
 	return nil
 }
 
-// Attendance model
+// Location returns the school's time zone, falling back to DefaultTimezone
+func (s *School) Location() *time.Location {
+	if s != nil && s.Timezone != "" {
+		if loc, err := time.LoadLocation(s.Timezone); err == nil {
+			return loc
+		}
+	}
+	return DefaultLocation()
+}
+
+// DefaultLocation returns the location of DefaultTimezone
+func DefaultLocation() *time.Location {
+	loc, err := time.LoadLocation(DefaultTimezone)
+	if err != nil {
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return loc
+}
+
+// Attendance sources, recording how a check-in or checkout was made
+const (
+	AttendanceSourceNFC    = "nfc"
+	AttendanceSourceQR     = "qr"
+	AttendanceSourceManual = "manual" // entered by a teacher for a student without their card
+	AttendanceSourceSystem = "system" // created by the absence job or a leave request
+)
+
+// AttendanceStatusEarlyLeave marks a daily record whose final checkout was
+// before the school's dismissal time
+const AttendanceStatusEarlyLeave = "early_leave"
+
+// Attendance model.
+// A student has at most one daily record (SessionID nil) and one record per
+// session on a date, enforced by unique indexes. Leaving and returning on the
+// same day adds Intervals; TimeIn is the first check-in and TimeOut the last
+// checkout, nil while the student is present.
 type Attendance struct {
-	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	StudentID uuid.UUID  `json:"student_id" gorm:"type:uuid;not null"`
-	Student   Student    `json:"student" gorm:"foreignKey:StudentID"`
-	Date      time.Time  `json:"date" gorm:"not null"`
-	TimeIn    *time.Time `json:"time_in"`
-	TimeOut   *time.Time `json:"time_out"`
-	Status    string     `json:"status" gorm:"not null;default:'present'"` // present, late, absent
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	ID                 uuid.UUID            `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	StudentID          uuid.UUID            `json:"student_id" gorm:"type:uuid;not null;uniqueIndex:idx_attendance_student_date,where:session_id IS NULL;uniqueIndex:idx_attendance_student_session"`
+	Student            Student              `json:"student" gorm:"foreignKey:StudentID"`
+	Date               time.Time            `json:"date" gorm:"not null;uniqueIndex:idx_attendance_student_date;uniqueIndex:idx_attendance_student_session"`
+	SessionID          *uuid.UUID           `json:"session_id" gorm:"type:uuid;uniqueIndex:idx_attendance_student_session"` // nil for the daily gate check-in
+	Session            *AttendanceSession   `json:"session,omitempty" gorm:"foreignKey:SessionID"`
+	TimeIn             *time.Time           `json:"time_in"`
+	TimeOut            *time.Time           `json:"time_out"`
+	Status             string               `json:"status" gorm:"not null;default:'present'"`     // present, late, absent, excused, sick, early_leave
+	NonSchoolDay       bool                 `json:"non_school_day" gorm:"not null;default:false"` // tap recorded on a weekend, holiday or outside term
+	LeaveRequestID     *uuid.UUID           `json:"leave_request_id" gorm:"type:uuid"`            // set when the status comes from an approved leave request
+	DeviceID           *uuid.UUID           `json:"device_id" gorm:"type:uuid;index"`             // reader that recorded the check-in
+	CheckoutDeviceID   *uuid.UUID           `json:"checkout_device_id" gorm:"type:uuid"`          // reader that recorded the checkout
+	Source             string               `json:"source" gorm:"not null;default:'nfc'"`         // nfc, qr, manual, system
+	CheckoutSource     string               `json:"checkout_source"`                              // nfc, qr, manual or system, empty until checkout
+	AutoCheckout       bool                 `json:"auto_checkout" gorm:"not null;default:false"`  // TimeOut was inferred by the auto-checkout job, not tapped
+	PresentMinutes     int                  `json:"present_minutes" gorm:"not null;default:0"`    // total of the closed intervals
+	Intervals          []AttendanceInterval `json:"intervals,omitempty" gorm:"foreignKey:AttendanceID;constraint:OnDelete:CASCADE"`
+	ManualReason       string               `json:"manual_reason"`                           // why a manual check-in was entered
+	RecordedBy         *uuid.UUID           `json:"recorded_by" gorm:"type:uuid"`            // teacher who entered a manual check-in
+	CheckoutReason     string               `json:"checkout_reason"`                         // why a manual checkout was entered
+	CheckoutRecordedBy *uuid.UUID           `json:"checkout_recorded_by" gorm:"type:uuid"`   // teacher who entered a manual checkout
+	VoidedAt           gorm.DeletedAt       `json:"voided_at" gorm:"column:voided_at;index"` // set when an admin voided the record; voided rows are left out of queries
+	VoidedBy           *uuid.UUID           `json:"voided_by" gorm:"type:uuid"`
+	CreatedAt          time.Time            `json:"created_at"`
+	UpdatedAt          time.Time            `json:"updated_at"`
 }
 
 // BeforeCreate hook for Attendance
@@ -86,5 +162,8 @@ func (a *Attendance) BeforeCreate(tx *gorm.DB) error {
 	if a.ID == uuid.Nil {
 		a.ID = uuid.New()
 	}
+	if a.Source == "" {
+		a.Source = AttendanceSourceNFC
+	}
 	return nil
-}
\ No newline at end of file
+}
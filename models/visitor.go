@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Visitor pass statuses
+const (
+	VisitorPassActive   = "active"
+	VisitorPassExpired  = "expired"  // validity window ended, closed by the scheduler
+	VisitorPassReturned = "returned" // card handed back at reception
+)
+
+// VisitorCard model is a card from the reception pool lent to visitors.
+// Its UID never belongs to a student or staff card.
+type VisitorCard struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SchoolID  uuid.UUID `json:"school_id" gorm:"type:uuid;not null;index"`
+	UID       string    `json:"uid" gorm:"uniqueIndex;not null"`
+	Label     string    `json:"label"` // number printed on the card, e.g. "TAMU 01"
+	IsActive  bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeCreate hook for VisitorCard
+func (v *VisitorCard) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
+
+// VisitorPass model lends a pooled card to a visitor for a validity window.
+// A card has at most one active pass; taps toggle the visitor between
+// inside and outside. Once the pass expires or is returned the card can be
+// issued again.
+type VisitorPass struct {
+	ID             uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SchoolID       uuid.UUID    `json:"school_id" gorm:"type:uuid;not null;index"`
+	CardID         uuid.UUID    `json:"card_id" gorm:"type:uuid;not null;index"`
+	Card           *VisitorCard `json:"card,omitempty" gorm:"foreignKey:CardID"`
+	VisitorName    string       `json:"visitor_name" gorm:"not null"`
+	IdentityNumber string       `json:"identity_number"` // KTP or other ID left at reception
+	Phone          string       `json:"phone"`
+	Host           string       `json:"host" gorm:"not null"` // person or office being visited
+	Purpose        string       `json:"purpose" gorm:"not null"`
+	ValidFrom      time.Time    `json:"valid_from" gorm:"not null"`
+	ValidUntil     time.Time    `json:"valid_until" gorm:"not null;index"`
+	Status         string       `json:"status" gorm:"not null;default:'active';index"` // active, expired, returned
+	Inside         bool         `json:"inside" gorm:"not null;default:false"`
+	EnteredAt      *time.Time   `json:"entered_at"` // first entry tap
+	ExitedAt       *time.Time   `json:"exited_at"`  // last exit tap
+	LastTapAt      *time.Time   `json:"last_tap_at"`
+	IssuedBy       *uuid.UUID   `json:"issued_by" gorm:"type:uuid"`
+	ClosedAt       *time.Time   `json:"closed_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// BeforeCreate hook for VisitorPass
+func (v *VisitorPass) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	if v.Status == "" {
+		v.Status = VisitorPassActive
+	}
+	return nil
+}
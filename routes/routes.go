@@ -16,14 +16,26 @@ func SetupRoutes(e *echo.Echo) {
 	// Initialize controllers
 	authController := &controllers.AuthController{}
 	attendanceController := &controllers.AttendanceController{}
+	scheduleController := &controllers.ScheduleController{}
+	calendarController := &controllers.CalendarController{}
+	leaveController := &controllers.LeaveController{}
+	sessionController := &controllers.SessionController{}
+	deviceController := &controllers.DeviceController{}
+	tapEventController := &controllers.TapEventController{}
+	cardController := &controllers.CardController{}
+	staffController := &controllers.StaffController{}
+	studentController := &controllers.StudentController{}
+	schoolController := &controllers.SchoolController{}
+	classController := &controllers.ClassController{}
+	visitorController := &controllers.VisitorController{}
 
 	// Public routes
 	api := e.Group("/api/v1")
-	
+
 	// Health check
 	api.GET("/health", func(c echo.Context) error {
 		return c.JSON(200, map[string]string{
-			"status": "OK",
+			"status":  "OK",
 			"message": "Attendance System API is running",
 		})
 	})
@@ -34,6 +46,14 @@ func SetupRoutes(e *echo.Echo) {
 	auth.POST("/register", authController.Register)
 	auth.POST("/refresh", authController.RefreshToken)
 
+	// Attendance recording accepts a user JWT or a signed request from a reader device
+	api.POST("/attendance/record", attendanceController.RecordAttendance,
+		middlewareCustom.DeviceOrJWTMiddleware(), middlewareCustom.IdempotencyMiddleware())
+	api.POST("/attendance/sync", attendanceController.SyncTaps, middlewareCustom.DeviceAuthMiddleware())
+	// QR tokens are only scanned by gate readers or staff, never submitted by the student
+	api.POST("/attendance/qr", attendanceController.RecordQRAttendance,
+		middlewareCustom.DeviceOrStaffMiddleware(), middlewareCustom.IdempotencyMiddleware())
+
 	// Protected routes (require JWT)
 	protected := api.Group("")
 	protected.Use(middlewareCustom.JWTMiddleware())
@@ -43,22 +63,117 @@ func SetupRoutes(e *echo.Echo) {
 
 	// Attendance routes (protected)
 	attendanceRoutes := protected.Group("/attendance")
-	attendanceRoutes.POST("/record", attendanceController.RecordAttendance)
 	attendanceRoutes.GET("/today", attendanceController.GetTodayAttendance)
 	attendanceRoutes.GET("/history/:student_id", attendanceController.GetAttendanceHistory)
+	attendanceRoutes.GET("/class/:class_id", classController.GetClassAttendance)
+	attendanceRoutes.POST("/manual", attendanceController.ManualCheckIn, middlewareCustom.StaffOrAdminMiddleware(), middlewareCustom.IdempotencyMiddleware())
+
+	// Rotating QR token for students without their card
+	protected.GET("/students/:student_id/qr-token", attendanceController.GetQRToken)
+
+	// Leave request routes (izin/sakit)
+	leaveRoutes := protected.Group("/leave-requests")
+	leaveRoutes.POST("", leaveController.SubmitLeave)
+	leaveRoutes.GET("", leaveController.ListLeaves)
+	leaveRoutes.GET("/:id", leaveController.GetLeave)
 
 	// Admin routes (require admin role)
 	admin := protected.Group("/admin")
 	admin.Use(middlewareCustom.AdminMiddleware())
-	admin.POST("/nfc/register", attendanceController.RegisterNFCCard)
+	admin.POST("/nfc/register", attendanceController.RegisterNFCCard, middlewareCustom.IdempotencyMiddleware())
+
+	// Students; a student is always created together with their first card
+	admin.GET("/students", studentController.ListStudents)
+	admin.POST("/students", attendanceController.RegisterNFCCard, middlewareCustom.IdempotencyMiddleware())
+	admin.GET("/students/:id", studentController.GetStudent)
+	admin.PUT("/students/:id", studentController.UpdateStudent)
+	admin.DELETE("/students/:id", studentController.DeactivateStudent)
+
+	// Homeroom classes per academic year
+	admin.GET("/classes", classController.ListClasses)
+	admin.POST("/classes", classController.CreateClass)
+	admin.PUT("/classes/:id", classController.UpdateClass)
+
+	// NFC card lifecycle
+	admin.GET("/cards", cardController.ListCards)
+	admin.POST("/cards/:id/lost", cardController.ReportLost)
+	admin.POST("/cards/:id/block", cardController.BlockCard)
+	admin.POST("/cards/:id/replace", cardController.ReplaceCard)
+	admin.POST("/cards/:id/ndef-payload", cardController.GenerateNDEFPayload)
+	admin.POST("/students/:student_id/cards", cardController.IssueCredential)
+	admin.PUT("/schools/:school_id/credential-limit", cardController.UpdateCredentialLimit)
+
+	admin.POST("/attendance/mark-absent", attendanceController.MarkAbsent)
+
+	// Attendance corrections, every change is audited
+	admin.POST("/attendance", attendanceController.CreateAttendance)
+	admin.PUT("/attendance/:id", attendanceController.UpdateAttendance)
+	admin.POST("/attendance/:id/void", attendanceController.VoidAttendance)
+	admin.GET("/attendance/:id/audit", attendanceController.GetAttendanceAudit)
+
+	admin.POST("/leave-requests/:id/approve", leaveController.ApproveLeave)
+	admin.POST("/leave-requests/:id/reject", leaveController.RejectLeave)
+	admin.GET("/schools/:school_id/schedule", scheduleController.GetSchedule)
+	admin.PUT("/schools/:school_id/schedule", scheduleController.UpdateSchedule)
+	admin.PUT("/schools/:school_id/timezone", scheduleController.UpdateTimezone)
+
+	// School calendar (holidays, half-days, academic terms)
+	calendar := admin.Group("/calendar")
+	calendar.GET("/events", calendarController.ListEvents)
+	calendar.POST("/events", calendarController.CreateEvent)
+	calendar.PUT("/events/:id", calendarController.UpdateEvent)
+	calendar.DELETE("/events/:id", calendarController.DeleteEvent)
+	calendar.GET("/terms", calendarController.ListTerms)
+	calendar.POST("/terms", calendarController.CreateTerm)
+	calendar.PUT("/terms/:id", calendarController.UpdateTerm)
+	calendar.DELETE("/terms/:id", calendarController.DeleteTerm)
+	calendar.GET("/schools/:school_id/days/:date", calendarController.GetDay)
+
+	// Attendance sessions (periods, extracurriculars, exams)
+	admin.GET("/sessions", sessionController.ListSessions)
+	admin.POST("/sessions", sessionController.CreateSession)
+	admin.PUT("/sessions/:id", sessionController.UpdateSession)
+	admin.DELETE("/sessions/:id", sessionController.DeactivateSession)
+
+	// NFC reader devices
+	admin.GET("/devices", deviceController.ListDevices)
+	admin.POST("/devices", deviceController.CreateDevice)
+	admin.PUT("/devices/:id", deviceController.UpdateDevice)
+	admin.POST("/devices/:id/rotate-secret", deviceController.RotateDeviceSecret)
+	admin.DELETE("/devices/:id", deviceController.DisableDevice)
+	admin.GET("/tap-events", tapEventController.ListTapEvents)
+
+	// Staff and teachers, who tap on the same readers as students
+	admin.GET("/staff", staffController.ListStaff)
+	admin.POST("/staff", staffController.CreateStaff)
+	admin.PUT("/staff/:id", staffController.UpdateStaff)
+	admin.POST("/staff/:id/cards", staffController.IssueStaffCard)
+	admin.GET("/staff-shifts", staffController.ListShifts)
+	admin.POST("/staff-shifts", staffController.CreateShift)
+	admin.PUT("/staff-shifts/:id", staffController.UpdateShift)
+	admin.GET("/staff-attendance", staffController.ListStaffAttendance)
+	admin.GET("/staff-attendance/report", staffController.StaffAttendanceReport)
+
+	// Visitor passes on pooled reception cards
+	admin.GET("/visitor-cards", visitorController.ListVisitorCards)
+	admin.POST("/visitor-cards", visitorController.CreateVisitorCard)
+	admin.PUT("/visitor-cards/:id", visitorController.UpdateVisitorCard)
+	admin.GET("/visitor-passes", visitorController.ListVisitorPasses)
+	admin.POST("/visitor-passes", visitorController.IssueVisitorPass, middlewareCustom.IdempotencyMiddleware())
+	admin.POST("/visitor-passes/:id/return", visitorController.ReturnVisitorPass)
 
 	// Super admin routes (require super admin role)
 	superAdmin := protected.Group("/super-admin")
 	superAdmin.Use(middlewareCustom.SuperAdminMiddleware())
+	superAdmin.GET("/schools", schoolController.ListSchools)
+	superAdmin.POST("/schools", schoolController.CreateSchool)
+	superAdmin.GET("/schools/:id", schoolController.GetSchool)
+	superAdmin.PUT("/schools/:id", schoolController.UpdateSchool)
+	superAdmin.DELETE("/schools/:id", schoolController.DeactivateSchool)
 	// Add super admin specific routes here
 	superAdmin.GET("/users", func(c echo.Context) error {
 		return c.JSON(200, map[string]string{
 			"message": "Super admin users endpoint",
 		})
 	})
-}
\ No newline at end of file
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"myapp/config"
+	"myapp/jobs"
+	"myapp/migrations"
 	"myapp/models"
 	"myapp/routes"
 )
@@ -21,17 +23,49 @@ func main() {
 	// Initialize database connection
 	config.ConnectDatabase()
 
+	// Clean up data that would fail the schema migration, e.g. duplicate attendances
+	if err := migrations.Prepare(config.DB); err != nil {
+		log.Fatal("Failed to prepare database migration:", err)
+	}
+
 	// Auto-migrate database tables
 	err = config.DB.AutoMigrate(
 		&models.User{},
 		&models.School{},
 		&models.Attendance{},
 		&models.Student{},
+		&models.SchoolSchedule{},
+		&models.ScheduleOverride{},
+		&models.CalendarEvent{},
+		&models.AcademicTerm{},
+		&models.LeaveRequest{},
+		&models.AttendanceSession{},
+		&models.Device{},
+		&models.DeviceNonce{},
+		&models.TapEvent{},
+		&models.IdempotencyRecord{},
+		&models.StaffShift{},
+		&models.Staff{},
+		&models.StaffAttendance{},
+		&models.Class{},
+		&models.NFCCard{},
+		&models.VisitorCard{},
+		&models.VisitorPass{},
+		&models.AttendanceAudit{},
+		&models.AttendanceInterval{},
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	// Run data migrations
+	if err := migrations.Run(config.DB); err != nil {
+		log.Fatal("Failed to run data migrations:", err)
+	}
+
+	// Start background jobs (absent marking, cleanup)
+	jobs.Start()
+
 	// Initialize Echo
 	e := echo.New()
 
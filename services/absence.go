@@ -0,0 +1,48 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+	"myapp/config"
+	"myapp/models"
+)
+
+// MarkAbsentees creates an "absent" daily attendance record on date for every
+// active student of the school that has no daily record for that date yet.
+// It is safe to run repeatedly; students already recorded are left untouched.
+// Returns the number of records created.
+func MarkAbsentees(school models.School, date time.Time) (int, error) {
+	var students []models.Student
+	result := config.DB.
+		Where("school_id = ? AND is_active = ?", school.ID, true).
+		Where("NOT EXISTS (SELECT 1 FROM attendances WHERE attendances.student_id = students.id AND attendances.date = ? AND attendances.session_id IS NULL)", date).
+		Find(&students)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	if len(students) == 0 {
+		return 0, nil
+	}
+
+	attendances := make([]models.Attendance, 0, len(students))
+	for _, student := range students {
+		attendances = append(attendances, models.Attendance{
+			ID:        uuid.New(),
+			StudentID: student.ID,
+			Date:      date,
+			Status:    "absent",
+			Source:    models.AttendanceSourceSystem,
+		})
+	}
+
+	// A student tapping in meanwhile wins over the absent record
+	result = config.DB.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&attendances, 100)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return int(result.RowsAffected), nil
+}
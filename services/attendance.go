@@ -0,0 +1,29 @@
+package services
+
+import (
+	"gorm.io/gorm"
+	"myapp/models"
+)
+
+// ReviveAttendance turns a voided attendance back into an empty record so a
+// new tap, leave or manual entry can take its place. The unique indexes keep
+// voided rows in the way of a new insert, so the row itself is reused.
+// attendance must be locked by the caller, who fills and saves it.
+func ReviveAttendance(tx *gorm.DB, attendance *models.Attendance) error {
+	if err := tx.Where("attendance_id = ?", attendance.ID).Delete(&models.AttendanceInterval{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.Attendance{}).Where("id = ?", attendance.ID).
+		Updates(map[string]interface{}{"voided_at": nil, "voided_by": nil}).Error; err != nil {
+		return err
+	}
+
+	*attendance = models.Attendance{
+		ID:        attendance.ID,
+		StudentID: attendance.StudentID,
+		Date:      attendance.Date,
+		SessionID: attendance.SessionID,
+		CreatedAt: attendance.CreatedAt,
+	}
+	return nil
+}
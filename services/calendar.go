@@ -0,0 +1,95 @@
+package services
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"myapp/models"
+)
+
+// DayInfo describes how a calendar date is treated for a school
+type DayInfo struct {
+	Date        time.Time          `json:"date"`
+	IsSchoolDay bool               `json:"is_school_day"`
+	Reason      string             `json:"reason,omitempty"` // why the date is not a school day
+	IsHalfDay   bool               `json:"is_half_day"`
+	Schedule    models.DaySchedule `json:"schedule"`
+}
+
+// ResolveDay evaluates the school calendar for date (a calendar date as stored
+// on Attendance). Weekdays outside the schedule's school days (by default
+// Saturday and Sunday), holidays and days outside the school's academic terms
+// are not school days; half-days adjust the day's schedule.
+// school.Schedule.Overrides should be preloaded. Pass the open transaction as
+// db when resolving inside one.
+func ResolveDay(db *gorm.DB, school models.School, date time.Time) (DayInfo, error) {
+	info := DayInfo{
+		Date:        date,
+		IsSchoolDay: true,
+		Schedule:    school.Schedule.ForWeekday(date.Weekday()),
+	}
+
+	if !school.Schedule.OnSchoolDay(date.Weekday()) {
+		info.IsSchoolDay = false
+		info.Reason = "no classes on this weekday"
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			info.Reason = "weekend"
+		}
+		return info, nil
+	}
+
+	var events []models.CalendarEvent
+	result := db.
+		Where("school_id = ? OR school_id IS NULL", school.ID).
+		Where("start_date <= ? AND end_date >= ?", date, date).
+		Find(&events)
+	if result.Error != nil {
+		return info, result.Error
+	}
+
+	for _, event := range events {
+		switch event.Type {
+		case models.CalendarEventHoliday:
+			info.IsSchoolDay = false
+			info.Reason = event.Name
+			return info, nil
+		case models.CalendarEventHalfDay:
+			info.IsHalfDay = true
+			if event.CheckoutOpenTime != nil {
+				info.Schedule.CheckoutOpenTime = *event.CheckoutOpenTime
+			}
+			if event.DismissalTime != nil {
+				info.Schedule.DismissalTime = *event.DismissalTime
+			}
+		}
+	}
+
+	var terms []models.AcademicTerm
+	if result := db.Where("school_id = ?", school.ID).Find(&terms); result.Error != nil {
+		return info, result.Error
+	}
+	if len(terms) > 0 {
+		inTerm := false
+		for _, term := range terms {
+			if !date.Before(term.StartDate) && !date.After(term.EndDate) {
+				inTerm = true
+				break
+			}
+		}
+		if !inTerm {
+			info.IsSchoolDay = false
+			info.Reason = "outside academic term"
+		}
+	}
+
+	return info, nil
+}
+
+// IsSchoolDay reports whether date is a school day for the school
+func IsSchoolDay(db *gorm.DB, school models.School, date time.Time) (bool, error) {
+	info, err := ResolveDay(db, school, date)
+	if err != nil {
+		return false, err
+	}
+	return info.IsSchoolDay, nil
+}
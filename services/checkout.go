@@ -0,0 +1,108 @@
+package services
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"myapp/config"
+	"myapp/models"
+	"myapp/utils"
+)
+
+// CloseOpenAttendance closes every attendance of the school up to now that
+// has a check-in but no checkout and whose close time has passed. Daily
+// records are closed at the day's dismissal time and session records at the
+// session's end time. A student who (re-)entered after that time is closed at
+// their last entry once the day is over. Closed records are flagged
+// AutoCheckout so reports can tell them from real checkouts.
+// school.Schedule.Overrides should be preloaded. Returns the number of
+// records closed.
+func CloseOpenAttendance(school models.School, now time.Time) (int, error) {
+	loc := school.Location()
+	today := utils.LocalDate(now, loc)
+
+	var open []models.Attendance
+	result := config.DB.Preload("Session").
+		Joins("JOIN students ON students.id = attendances.student_id").
+		Where("students.school_id = ?", school.ID).
+		Where("attendances.date <= ? AND attendances.time_in IS NOT NULL AND attendances.time_out IS NULL", today).
+		Find(&open)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	days := make(map[time.Time]DayInfo)
+	count := 0
+	for _, attendance := range open {
+		day, ok := days[attendance.Date]
+		if !ok {
+			var err error
+			if day, err = ResolveDay(config.DB, school, attendance.Date); err != nil {
+				return count, err
+			}
+			days[attendance.Date] = day
+		}
+
+		clock := day.Schedule.DismissalTime
+		if attendance.Session != nil {
+			clock = attendance.Session.EndTime
+		}
+		midnight := time.Date(attendance.Date.Year(), attendance.Date.Month(), attendance.Date.Day(), 0, 0, 0, 0, loc)
+		timeOut, err := utils.ClockOn(midnight, clock)
+		if err != nil {
+			return count, err
+		}
+		if timeOut.After(now) {
+			continue
+		}
+
+		closed := false
+		err = config.DB.Transaction(func(tx *gorm.DB) error {
+			// A checkout recorded meanwhile wins
+			var current models.Attendance
+			result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND time_out IS NULL", attendance.ID).Limit(1).Find(&current)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+
+			// Entries after the close time stay open until the day is over
+			lastEntry := *current.TimeIn
+			var interval models.AttendanceInterval
+			result = tx.Where("attendance_id = ? AND time_out IS NULL", current.ID).Order("time_in DESC").Limit(1).Find(&interval)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 1 && interval.TimeIn.After(lastEntry) {
+				lastEntry = interval.TimeIn
+			}
+			closeAt := timeOut
+			if closeAt.Before(lastEntry) {
+				if current.Date.Equal(today) {
+					return nil
+				}
+				closeAt = lastEntry
+			}
+
+			if err := CloseInterval(tx, &current, closeAt, nil, models.AttendanceSourceSystem); err != nil {
+				return err
+			}
+			closed = true
+			return tx.Model(&current).Updates(map[string]interface{}{
+				"time_out":        closeAt,
+				"auto_checkout":   true,
+				"checkout_source": models.AttendanceSourceSystem,
+				"present_minutes": current.PresentMinutes,
+			}).Error
+		})
+		if err != nil {
+			return count, err
+		}
+		if closed {
+			count++
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"myapp/models"
+)
+
+// OpenInterval starts a new interval of presence at in
+func OpenInterval(tx *gorm.DB, attendance models.Attendance, in time.Time, deviceID *uuid.UUID, source string) error {
+	interval := models.AttendanceInterval{
+		ID:           uuid.New(),
+		AttendanceID: attendance.ID,
+		TimeIn:       in,
+		DeviceID:     deviceID,
+		Source:       source,
+	}
+	return tx.Create(&interval).Error
+}
+
+// BackfillInterval creates the first interval of a record from before
+// intervals existed from its TimeIn and TimeOut, so a re-entry adds a second
+// interval instead of replacing the time already spent
+func BackfillInterval(tx *gorm.DB, attendance *models.Attendance) error {
+	if attendance.TimeIn == nil {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.AttendanceInterval{}).Where("attendance_id = ?", attendance.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return ResetIntervals(tx, attendance)
+}
+
+// LastEntry returns when the student last entered: the start of the open
+// interval, or TimeIn for records without one
+func LastEntry(tx *gorm.DB, attendance models.Attendance) (time.Time, error) {
+	var open models.AttendanceInterval
+	result := tx.Where("attendance_id = ? AND time_out IS NULL", attendance.ID).Order("time_in DESC").Limit(1).Find(&open)
+	if result.Error != nil {
+		return time.Time{}, result.Error
+	}
+	if result.RowsAffected == 0 || attendance.TimeIn.After(open.TimeIn) {
+		return *attendance.TimeIn, nil
+	}
+	return open.TimeIn, nil
+}
+
+// MoveFirstCheckIn moves the start of the attendance's first interval to in,
+// for an offline tap replayed after a later check-in
+func MoveFirstCheckIn(tx *gorm.DB, attendance models.Attendance, in time.Time, deviceID *uuid.UUID, source string) error {
+	var first models.AttendanceInterval
+	result := tx.Where("attendance_id = ?", attendance.ID).Order("time_in ASC").Limit(1).Find(&first)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return OpenInterval(tx, attendance, in, deviceID, source)
+	}
+
+	first.TimeIn = in
+	first.DeviceID = deviceID
+	first.Source = source
+	return tx.Save(&first).Error
+}
+
+// CloseInterval ends the open interval of the attendance at out and updates
+// attendance.PresentMinutes. Records from before intervals existed get their
+// first interval created from TimeIn.
+func CloseInterval(tx *gorm.DB, attendance *models.Attendance, out time.Time, deviceID *uuid.UUID, source string) error {
+	var open models.AttendanceInterval
+	result := tx.Where("attendance_id = ? AND time_out IS NULL", attendance.ID).Order("time_in DESC").Limit(1).Find(&open)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := tx.Model(&models.AttendanceInterval{}).Where("attendance_id = ?", attendance.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 || attendance.TimeIn == nil {
+			return UpdatePresentMinutes(tx, attendance)
+		}
+		open = models.AttendanceInterval{
+			ID:           uuid.New(),
+			AttendanceID: attendance.ID,
+			TimeIn:       *attendance.TimeIn,
+			DeviceID:     attendance.DeviceID,
+			Source:       attendance.Source,
+		}
+	}
+
+	open.TimeOut = &out
+	open.CheckoutDeviceID = deviceID
+	open.CheckoutSource = source
+	if err := tx.Save(&open).Error; err != nil {
+		return err
+	}
+	return UpdatePresentMinutes(tx, attendance)
+}
+
+// ResetIntervals replaces the intervals of a corrected attendance with a
+// single one spanning its TimeIn and TimeOut
+func ResetIntervals(tx *gorm.DB, attendance *models.Attendance) error {
+	if err := tx.Where("attendance_id = ?", attendance.ID).Delete(&models.AttendanceInterval{}).Error; err != nil {
+		return err
+	}
+	if attendance.TimeIn != nil {
+		interval := models.AttendanceInterval{
+			ID:               uuid.New(),
+			AttendanceID:     attendance.ID,
+			TimeIn:           *attendance.TimeIn,
+			TimeOut:          attendance.TimeOut,
+			DeviceID:         attendance.DeviceID,
+			CheckoutDeviceID: attendance.CheckoutDeviceID,
+			Source:           attendance.Source,
+			CheckoutSource:   attendance.CheckoutSource,
+		}
+		if err := tx.Create(&interval).Error; err != nil {
+			return err
+		}
+	}
+	return UpdatePresentMinutes(tx, attendance)
+}
+
+// UpdatePresentMinutes sets attendance.PresentMinutes to the total length of
+// its closed intervals. The caller saves the attendance.
+func UpdatePresentMinutes(tx *gorm.DB, attendance *models.Attendance) error {
+	var intervals []models.AttendanceInterval
+	if err := tx.Where("attendance_id = ? AND time_out IS NOT NULL", attendance.ID).Find(&intervals).Error; err != nil {
+		return err
+	}
+
+	var total time.Duration
+	for _, interval := range intervals {
+		total += interval.TimeOut.Sub(interval.TimeIn)
+	}
+	attendance.PresentMinutes = int(total / time.Minute)
+	return nil
+}
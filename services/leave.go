@@ -0,0 +1,57 @@
+package services
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"myapp/models"
+)
+
+// ApplyLeave writes the outcome of an approved leave request onto the
+// student's attendance for every school day in the request's date range.
+// Missing days and days without a tap (absent) get the leave type as status;
+// days the student actually tapped in are left untouched.
+// Returns the number of attendance rows created or updated.
+func ApplyLeave(tx *gorm.DB, leave models.LeaveRequest, school models.School) (int, error) {
+	count := 0
+	for date := leave.StartDate; !date.After(leave.EndDate); date = date.AddDate(0, 0, 1) {
+		schoolDay, err := IsSchoolDay(tx, school, date)
+		if err != nil {
+			return count, err
+		}
+		if !schoolDay {
+			continue
+		}
+
+		var attendance models.Attendance
+		result := tx.Unscoped().Where("student_id = ? AND date = ? AND session_id IS NULL", leave.StudentID, date).Limit(1).Find(&attendance)
+		if result.Error != nil {
+			return count, result.Error
+		}
+		if attendance.VoidedAt.Valid {
+			if err := ReviveAttendance(tx, &attendance); err != nil {
+				return count, err
+			}
+			attendance.Source = models.AttendanceSourceSystem
+		}
+
+		if result.RowsAffected == 0 {
+			attendance = models.Attendance{
+				ID:        uuid.New(),
+				StudentID: leave.StudentID,
+				Date:      date,
+				Source:    models.AttendanceSourceSystem,
+			}
+		} else if attendance.TimeIn != nil {
+			continue
+		}
+
+		attendance.Status = leave.Type
+		attendance.LeaveRequestID = &leave.ID
+		if err := tx.Save(&attendance).Error; err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
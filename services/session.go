@@ -0,0 +1,66 @@
+package services
+
+import (
+	"time"
+
+	"myapp/config"
+	"myapp/models"
+	"myapp/utils"
+)
+
+// SessionEarlyWindow is how long before a session starts taps are accepted for it
+const SessionEarlyWindow = 15 * time.Minute
+
+// SessionCheckoutWindow is how long before and after a session ends taps are
+// accepted as the session's checkout
+const SessionCheckoutWindow = 15 * time.Minute
+
+// ResolveSession finds the session a tap at now (in the school's local time)
+// from a reader at location belongs to. Sessions bound to the reader's
+// location win over sessions open to any reader; among equals the one that
+// started last wins. Returns nil when the tap is a regular daily check-in.
+func ResolveSession(school models.School, location string, now time.Time) (*models.AttendanceSession, error) {
+	var sessions []models.AttendanceSession
+	query := config.DB.Where("school_id = ? AND is_active = ?", school.ID, true)
+	if location != "" {
+		query = query.Where("location = ? OR location = ''", location)
+	} else {
+		query = query.Where("location = ''")
+	}
+	if result := query.Find(&sessions); result.Error != nil {
+		return nil, result.Error
+	}
+
+	var match *models.AttendanceSession
+	var matchStart time.Time
+	for i := range sessions {
+		session := &sessions[i]
+		if !session.OnWeekday(now.Weekday()) {
+			continue
+		}
+
+		start, err := utils.ClockOn(now, session.StartTime)
+		if err != nil {
+			continue
+		}
+		end, err := utils.ClockOn(now, session.EndTime)
+		if err != nil {
+			continue
+		}
+		if now.Before(start.Add(-SessionEarlyWindow)) || now.After(end.Add(SessionCheckoutWindow)) {
+			continue
+		}
+
+		if match != nil {
+			specific := session.Location != "" && match.Location == ""
+			sameScope := (session.Location != "") == (match.Location != "")
+			if !specific && !(sameScope && start.After(matchStart)) {
+				continue
+			}
+		}
+		match = session
+		matchStart = start
+	}
+
+	return match, nil
+}
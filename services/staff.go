@@ -0,0 +1,155 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"myapp/config"
+	"myapp/models"
+	"myapp/utils"
+)
+
+// MarkStaffAbsentees creates an "absent" record on date for every active
+// staff member of the school whose shift is worked that weekday, has ended
+// by now, and who has no record for the date yet. Only school days count, so
+// holidays and breaks never produce absences. It is safe to run repeatedly.
+// school.Schedule.Overrides should be preloaded. Returns the number of
+// records created.
+func MarkStaffAbsentees(school models.School, date time.Time, now time.Time) (int, error) {
+	day, err := ResolveDay(config.DB, school, date)
+	if err != nil || !day.IsSchoolDay {
+		return 0, err
+	}
+
+	var staff []models.Staff
+	result := config.DB.Preload("Shift").
+		Where("school_id = ? AND is_active = ? AND shift_id IS NOT NULL", school.ID, true).
+		Where("NOT EXISTS (SELECT 1 FROM staff_attendances WHERE staff_attendances.staff_id = staffs.id AND staff_attendances.date = ?)", date).
+		Find(&staff)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, school.Location())
+	attendances := make([]models.StaffAttendance, 0, len(staff))
+	for _, member := range staff {
+		shift := member.Shift
+		if shift == nil || !shift.IsActive || !shift.OnWeekday(date.Weekday()) {
+			continue
+		}
+		shiftEnd, err := utils.ClockOn(midnight, shift.EndTime)
+		if err != nil {
+			return 0, err
+		}
+		if shiftEnd.After(now) {
+			continue
+		}
+		attendances = append(attendances, models.StaffAttendance{
+			ID:      uuid.New(),
+			StaffID: member.ID,
+			Date:    date,
+			ShiftID: &shift.ID,
+			Status:  models.StaffStatusAbsent,
+			Source:  models.AttendanceSourceSystem,
+		})
+	}
+
+	if len(attendances) == 0 {
+		return 0, nil
+	}
+
+	// A staff member tapping in meanwhile wins over the absent record
+	result = config.DB.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&attendances, 100)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return int(result.RowsAffected), nil
+}
+
+// CloseOpenStaffAttendance closes every staff attendance of the school up to
+// now that has a check-in but no checkout and whose close time has passed.
+// Records are closed at the end of their shift, or at the day's dismissal
+// time when no shift applied. A staff member who checked in after that time
+// is closed at their check-in once the day is over. Closed records are
+// flagged AutoCheckout so the HR report can tell them from real checkouts.
+// school.Schedule.Overrides should be preloaded. Returns the number of
+// records closed.
+func CloseOpenStaffAttendance(school models.School, now time.Time) (int, error) {
+	loc := school.Location()
+	today := utils.LocalDate(now, loc)
+
+	var open []models.StaffAttendance
+	result := config.DB.Preload("Shift").
+		Joins("JOIN staffs ON staffs.id = staff_attendances.staff_id").
+		Where("staffs.school_id = ?", school.ID).
+		Where("staff_attendances.date <= ? AND staff_attendances.time_in IS NOT NULL AND staff_attendances.time_out IS NULL", today).
+		Find(&open)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	days := make(map[time.Time]DayInfo)
+	count := 0
+	for _, attendance := range open {
+		clock := ""
+		if attendance.Shift != nil {
+			clock = attendance.Shift.EndTime
+		} else {
+			day, ok := days[attendance.Date]
+			if !ok {
+				var err error
+				if day, err = ResolveDay(config.DB, school, attendance.Date); err != nil {
+					return count, err
+				}
+				days[attendance.Date] = day
+			}
+			clock = day.Schedule.DismissalTime
+		}
+
+		midnight := time.Date(attendance.Date.Year(), attendance.Date.Month(), attendance.Date.Day(), 0, 0, 0, 0, loc)
+		timeOut, err := utils.ClockOn(midnight, clock)
+		if err != nil {
+			return count, err
+		}
+		if timeOut.After(now) {
+			continue
+		}
+
+		closed := false
+		err = config.DB.Transaction(func(tx *gorm.DB) error {
+			// A checkout recorded meanwhile wins
+			var current models.StaffAttendance
+			result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND time_out IS NULL", attendance.ID).Limit(1).Find(&current)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+
+			// A check-in after the close time stays open until the day is over
+			closeAt := timeOut
+			if closeAt.Before(*current.TimeIn) {
+				if current.Date.Equal(today) {
+					return nil
+				}
+				closeAt = *current.TimeIn
+			}
+
+			closed = true
+			return tx.Model(&current).Updates(map[string]interface{}{
+				"time_out":      closeAt,
+				"auto_checkout": true,
+			}).Error
+		})
+		if err != nil {
+			return count, err
+		}
+		if closed {
+			count++
+		}
+	}
+
+	return count, nil
+}
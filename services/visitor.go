@@ -0,0 +1,22 @@
+package services
+
+import (
+	"time"
+
+	"myapp/config"
+	"myapp/models"
+)
+
+// ExpireVisitorPasses closes every active visitor pass whose validity window
+// ended by now, so its card can be issued again. Visitors who never tapped
+// out keep inside=true for reception to follow up. Returns the number of
+// passes expired.
+func ExpireVisitorPasses(now time.Time) (int64, error) {
+	result := config.DB.Model(&models.VisitorPass{}).
+		Where("status = ? AND valid_until <= ?", models.VisitorPassActive, now).
+		Updates(map[string]interface{}{
+			"status":    models.VisitorPassExpired,
+			"closed_at": now,
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// cardPayloadVersion prefixes every signed card payload
+const cardPayloadVersion = "ATT1"
+
+// CardPayloadMimeType is the NDEF MIME record type the payload is written as
+const CardPayloadMimeType = "application/vnd.attendance.card"
+
+var (
+	ErrCardSigningDisabled = errors.New("card signing key is not configured")
+	ErrInvalidCardPayload  = errors.New("invalid card payload")
+)
+
+// CardPayload is the content of the signed NDEF record on a card
+type CardPayload struct {
+	StudentID uuid.UUID
+	CardID    uuid.UUID
+	Counter   int64
+}
+
+func getCardSigningKey() []byte {
+	return []byte(os.Getenv("CARD_SIGNING_KEY"))
+}
+
+// SignCardPayload encodes p as "ATT1:<student>:<card>:<counter>:<signature>",
+// the signature being a base64url HMAC-SHA256 of the preceding fields
+func SignCardPayload(p CardPayload) (string, error) {
+	key := getCardSigningKey()
+	if len(key) == 0 {
+		return "", ErrCardSigningDisabled
+	}
+
+	data := fmt.Sprintf("%s:%s:%s:%d", cardPayloadVersion, p.StudentID, p.CardID, p.Counter)
+	return data + ":" + signCardData(key, data), nil
+}
+
+// VerifyCardPayload checks the signature of a payload made by
+// SignCardPayload and returns its content
+func VerifyCardPayload(payload string) (CardPayload, error) {
+	var p CardPayload
+
+	key := getCardSigningKey()
+	if len(key) == 0 {
+		return p, ErrCardSigningDisabled
+	}
+
+	i := strings.LastIndex(payload, ":")
+	if i < 0 {
+		return p, ErrInvalidCardPayload
+	}
+	data, signature := payload[:i], payload[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(signCardData(key, data))) {
+		return p, ErrInvalidCardPayload
+	}
+
+	parts := strings.Split(data, ":")
+	if len(parts) != 4 || parts[0] != cardPayloadVersion {
+		return p, ErrInvalidCardPayload
+	}
+
+	var err error
+	if p.StudentID, err = uuid.Parse(parts[1]); err != nil {
+		return p, ErrInvalidCardPayload
+	}
+	if p.CardID, err = uuid.Parse(parts[2]); err != nil {
+		return p, ErrInvalidCardPayload
+	}
+	if p.Counter, err = strconv.ParseInt(parts[3], 10, 64); err != nil || p.Counter < 1 {
+		return p, ErrInvalidCardPayload
+	}
+
+	return p, nil
+}
+
+func signCardData(key []byte, data string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
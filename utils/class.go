@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// romanGrades maps grade levels 1-12 to the roman numerals schools use in
+// class names
+var romanGrades = []string{"", "I", "II", "III", "IV", "V", "VI", "VII", "VIII", "IX", "X", "XI", "XII"}
+
+// NormalizeClassName returns the canonical form of a free-text class name
+// and its grade level. The grade is written as a roman numeral and the rest
+// is upper-cased with single spaces, so "12 ipa 1", "XII  IPA 1",
+// "Kelas XII-IPA-1" and "xii ipa 1" all become "XII IPA 1" with grade 12.
+// Letters and digits glued together are split, so "7A" becomes "VII A" and
+// "XII IPA1" becomes "XII IPA 1". Names without a leading grade are only
+// cleaned up and have grade 0.
+func NormalizeClassName(raw string) (string, int) {
+	var b strings.Builder
+	var prev rune
+	for _, r := range strings.ToUpper(raw) {
+		switch {
+		case r == '-' || r == '_':
+			r = ' '
+		case prev != 0 && isDigit(prev) != isDigit(r) && !unicode.IsSpace(prev) && !unicode.IsSpace(r):
+			b.WriteRune(' ')
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	tokens := strings.Fields(b.String())
+	if len(tokens) > 1 && tokens[0] == "KELAS" && gradeToken(tokens[1]) > 0 {
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return "", 0
+	}
+
+	grade := gradeToken(tokens[0])
+	if grade == 0 {
+		return strings.Join(tokens, " "), 0
+	}
+	return strings.Join(append([]string{romanGrades[grade]}, tokens[1:]...), " "), grade
+}
+
+// isDigit reports whether r is an ASCII digit
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// gradeToken parses a class name token as a grade level, either a roman
+// numeral or 1-12. Returns 0 if the token is not a grade.
+func gradeToken(token string) int {
+	for grade, numeral := range romanGrades {
+		if grade > 0 && token == numeral {
+			return grade
+		}
+	}
+
+	if n, err := strconv.Atoi(token); err == nil && n >= 1 && n <= 12 {
+		return n
+	}
+	return 0
+}
+
+// AcademicYear returns the academic year containing t, e.g. "2025/2026".
+// The Indonesian school year starts in July.
+func AcademicYear(t time.Time) string {
+	year := t.Year()
+	if t.Month() < time.July {
+		year--
+	}
+	return fmt.Sprintf("%d/%d", year, year+1)
+}
+
+// ValidAcademicYear reports whether year has the form "2025/2026"
+func ValidAcademicYear(year string) bool {
+	start, end, ok := strings.Cut(year, "/")
+	if !ok || len(start) != 4 || len(end) != 4 {
+		return false
+	}
+	s, err1 := strconv.Atoi(start)
+	e, err2 := strconv.Atoi(end)
+	return err1 == nil && err2 == nil && e == s+1
+}
@@ -0,0 +1,37 @@
+package utils
+
+import "testing"
+
+func TestNormalizeClassName(t *testing.T) {
+	tests := []struct {
+		raw   string
+		name  string
+		grade int
+	}{
+		{"XII IPA 1", "XII IPA 1", 12},
+		{"12 ipa 1", "XII IPA 1", 12},
+		{"xii  ipa 1", "XII IPA 1", 12},
+		{"Kelas XII-IPA-1", "XII IPA 1", 12},
+		{"kelas 12_ipa_1", "XII IPA 1", 12},
+		{"XII IPA1", "XII IPA 1", 12},
+		{"12IPA1", "XII IPA 1", 12},
+		{"7A", "VII A", 7},
+		{"vii a", "VII A", 7},
+		{"1 B", "I B", 1},
+		{"X", "X", 10},
+		{"13 A", "13 A", 0},
+		{"Kelas", "KELAS", 0},
+		{"Kelas Tahfidz", "KELAS TAHFIDZ", 0},
+		{"  ", "", 0},
+		{"", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			name, grade := NormalizeClassName(tt.raw)
+			if name != tt.name || grade != tt.grade {
+				t.Errorf("NormalizeClassName(%q) = %q, %d, want %q, %d", tt.raw, name, grade, tt.name, tt.grade)
+			}
+		})
+	}
+}
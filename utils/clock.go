@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseClock parses a "HH:MM" wall clock time
+func ParseClock(clock string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid clock time %q, expected HH:MM", clock)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// ClockOn returns the instant of a "HH:MM" wall clock time on the day of t, in t's location
+func ClockOn(t time.Time, clock string) (time.Time, error) {
+	hour, minute, err := ParseClock(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, t.Location()), nil
+}
+
+// LocalDate returns the calendar date of t in loc as midnight UTC.
+// Attendance dates are stored in this form so a school day is keyed the same
+// way regardless of the school's time zone or the server's zone.
+func LocalDate(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ParseDate parses a "YYYY-MM-DD" calendar date into the LocalDate form
+func ParseDate(date string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+	}
+	return t, nil
+}
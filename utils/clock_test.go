@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestLocalDate(t *testing.T) {
+	tests := []struct {
+		name string
+		zone string
+		tap  time.Time // UTC instant of the tap
+		want string
+	}{
+		{"WIB just before midnight", "Asia/Jakarta", time.Date(2024, 7, 15, 16, 59, 59, 0, time.UTC), "2024-07-15"},
+		{"WIB at midnight", "Asia/Jakarta", time.Date(2024, 7, 15, 17, 0, 0, 0, time.UTC), "2024-07-16"},
+		{"WITA just before midnight", "Asia/Makassar", time.Date(2024, 7, 15, 15, 59, 59, 0, time.UTC), "2024-07-15"},
+		{"WITA at midnight", "Asia/Makassar", time.Date(2024, 7, 15, 16, 0, 0, 0, time.UTC), "2024-07-16"},
+		{"WIT just before midnight", "Asia/Jayapura", time.Date(2024, 7, 15, 14, 59, 59, 0, time.UTC), "2024-07-15"},
+		{"WIT at midnight", "Asia/Jayapura", time.Date(2024, 7, 15, 15, 0, 0, 0, time.UTC), "2024-07-16"},
+		{"WIT early morning is the previous UTC day", "Asia/Jayapura", time.Date(2024, 7, 15, 22, 30, 0, 0, time.UTC), "2024-07-16"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LocalDate(tt.tap, mustLoad(t, tt.zone))
+			if got.Format("2006-01-02") != tt.want {
+				t.Errorf("LocalDate = %s, want %s", got.Format("2006-01-02"), tt.want)
+			}
+			if got.Location() != time.UTC || got.Hour() != 0 || got.Minute() != 0 {
+				t.Errorf("LocalDate = %v, want midnight UTC", got)
+			}
+		})
+	}
+}
+
+func TestClockOnKeepsLocalDay(t *testing.T) {
+	// 00:30 WIT on 16 July is still 15 July in UTC
+	now := time.Date(2024, 7, 15, 15, 30, 0, 0, time.UTC).In(mustLoad(t, "Asia/Jayapura"))
+	got, err := ClockOn(now, "07:30")
+	if err != nil {
+		t.Fatalf("ClockOn: %v", err)
+	}
+	if got.Day() != 16 || got.Hour() != 7 || got.Minute() != 30 {
+		t.Errorf("ClockOn = %s, want 2024-07-16 07:30 WIT", got.Format(time.RFC3339))
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	for _, bad := range []string{"", "7:3", "24:00", "07:60", "0730"} {
+		if _, _, err := ParseClock(bad); err == nil {
+			t.Errorf("ParseClock(%q) succeeded, want error", bad)
+		}
+	}
+	hour, minute, err := ParseClock("07:05")
+	if err != nil || hour != 7 || minute != 5 {
+		t.Errorf("ParseClock(07:05) = %d, %d, %v", hour, minute, err)
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QRTokenPeriod is how long a rotating QR token stays current. The token of
+// the previous period is still accepted to allow for slow scans.
+const QRTokenPeriod = 30 * time.Second
+
+var ErrInvalidQRToken = errors.New("invalid QR token")
+
+// QRToken returns the rotating QR token of a student at t, formatted as
+// "<student>.<period>.<signature>" and signed with the student's QR secret.
+// It also returns when the token stops being current.
+func QRToken(studentID uuid.UUID, secret string, t time.Time) (string, time.Time) {
+	period := t.Unix() / int64(QRTokenPeriod/time.Second)
+	data := fmt.Sprintf("%s.%d", studentID, period)
+	expiresAt := time.Unix((period+1)*int64(QRTokenPeriod/time.Second), 0)
+	return data + "." + signQRData(secret, data), expiresAt
+}
+
+// ParseQRToken returns the student a QR token claims to belong to. The token
+// still has to be checked with VerifyQRToken using that student's secret.
+func ParseQRToken(token string) (uuid.UUID, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return uuid.Nil, ErrInvalidQRToken
+	}
+	studentID, err := uuid.Parse(parts[0])
+	if err != nil {
+		return uuid.Nil, ErrInvalidQRToken
+	}
+	return studentID, nil
+}
+
+// VerifyQRToken reports whether token was issued with secret for the period
+// of t or the one before it
+func VerifyQRToken(token, secret string, t time.Time) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || secret == "" {
+		return false
+	}
+
+	period, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return false
+	}
+	current := t.Unix() / int64(QRTokenPeriod/time.Second)
+	if period != current && period != current-1 {
+		return false
+	}
+
+	data := parts[0] + "." + parts[1]
+	return hmac.Equal([]byte(parts[2]), []byte(signQRData(secret, data)))
+}
+
+func signQRData(secret, data string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
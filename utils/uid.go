@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeUID returns the canonical form of an NFC UID: upper-case hex
+// without separators. Readers send UIDs as "04:A3:1B:22", "04-a3-1b-22" or
+// "04a31b22"; all normalize to "04A31B22". Only 4, 7 and 10 byte UIDs
+// (ISO 14443 single, double and triple size) are valid.
+func NormalizeUID(raw string) (string, error) {
+	uid := strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '-', ' ', '.':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+	uid = strings.ToUpper(uid)
+
+	for _, r := range uid {
+		if (r < '0' || r > '9') && (r < 'A' || r > 'F') {
+			return "", fmt.Errorf("invalid NFC UID %q, expected hex", raw)
+		}
+	}
+
+	switch len(uid) / 2 {
+	case 4, 7, 10:
+		if len(uid)%2 == 0 {
+			return uid, nil
+		}
+	}
+	return "", fmt.Errorf("invalid NFC UID %q, expected 4, 7 or 10 bytes", raw)
+}